@@ -0,0 +1,50 @@
+package qtffilst
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/tingtt/qtffilst/udta"
+)
+
+// ReadAssets reads the 3GPP asset information boxes directly under `.moov.udta` (e.g. `.moov.udta.titl`).
+func (r *reader) ReadAssets() (udta.Assets, error) {
+	assets := udta.Assets{}
+
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return udta.Assets{}, err
+		}
+		if !udtaAssetBox(box) {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		err = copy(r.f, box.DataPosition, box.DataSize, buf)
+		if err != nil {
+			return udta.Assets{}, err
+		}
+		err = assets.SetDecoded(box.Name, buf.Bytes())
+		if err != nil {
+			slog.Debug(fmt.Sprintf("box: %-36s (%v, %vB) invalid 3GPP asset (%s)\n", box.Path, box.DataPosition, box.DataSize, err))
+			continue
+		}
+	}
+	return assets, nil
+}
+
+// WriteAssets writes the 3GPP asset information boxes directly under `.moov.udta`.
+// The first box of each type is replaced, and `.moov.udta` is created if it does not exist.
+func (r *readWriter) WriteAssets(dest, tmpDest *os.File, assets udta.Assets, deleteIds []string) error {
+	err := assets.Validate()
+	if err != nil {
+		return err
+	}
+	boxes := map[string][]byte{}
+	for id, data := range assets.EncodedValues() {
+		boxes[id] = data
+	}
+	return r.writeUdtaBoxes(dest, tmpDest, boxes, deleteIds)
+}
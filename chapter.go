@@ -0,0 +1,149 @@
+package qtffilst
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/tingtt/qtffilst/chapter"
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+// ReadChapters reads the Nero chapters (`.moov.udta.chpl`)
+// and the QuickTime chapter track (text track referenced by `.moov.trak.tref.chap`).
+func (r *reader) ReadChapters() (chapter.Chapters, error) {
+	chapters := chapter.Chapters{}
+
+	tracks := []chapterTrackLayout{}
+	track := chapterTrackLayout{}
+	chapterTrackIds := []int32{}
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return chapter.Chapters{}, err
+		}
+
+		switch box.Path {
+		case ".moov.udta.chpl":
+			buf := &bytes.Buffer{}
+			err = copy(r.f, box.DataPosition, box.DataSize, buf)
+			if err != nil {
+				return chapter.Chapters{}, err
+			}
+			chapters.Nero, err = chapter.DecodeNero(buf.Bytes())
+			if err != nil {
+				return chapter.Chapters{}, fmt.Errorf("%s %w", box.Path, err)
+			}
+		case ".moov.trak":
+			if !box.IsContainable /* start of track */ {
+				track = chapterTrackLayout{}
+				continue
+			}
+			tracks = append(tracks, track)
+		case ".moov.trak.tkhd":
+			track.id, err = readTrackId(r.f, box)
+		case ".moov.trak.tref":
+			var trackIds []int32
+			trackIds, err = readTrackReferences(r.f, box, "chap")
+			chapterTrackIds = append(chapterTrackIds, trackIds...)
+		case ".moov.trak.mdia.mdhd":
+			track.timescale, _, err = readMediaHeader(r.f, box)
+		case ".moov.trak.mdia.minf.stbl.stts":
+			track.stts = box
+		case ".moov.trak.mdia.minf.stbl.stsc":
+			track.stsc = box
+		case ".moov.trak.mdia.minf.stbl.stsz":
+			track.stsz = box
+		case ".moov.trak.mdia.minf.stbl.stco", ".moov.trak.mdia.minf.stbl.co64":
+			track.chunkOffset = box
+		}
+		if err != nil {
+			return chapter.Chapters{}, err
+		}
+	}
+
+	for _, track := range tracks {
+		if !slices.Contains(chapterTrackIds, track.id) {
+			continue
+		}
+		var err error
+		chapters.Track, err = readTrackChapters(r.f, r.size, track)
+		if err != nil {
+			return chapter.Chapters{}, err
+		}
+		break
+	}
+	return chapters, nil
+}
+
+type chapterTrackLayout struct {
+	id        int32
+	timescale int64
+	// Sample table boxes (`.moov.trak.mdia.minf.stbl.*`)
+	stts, stsc, stsz, chunkOffset Box
+}
+
+// readTrackChapters reads the chapters from the samples of QuickTime text track.
+func readTrackChapters(rs io.ReadSeeker, size int64, track chapterTrackLayout) ([]chapter.Chapter, error) {
+	if track.timescale == 0 || track.stts.Name == "" || track.stsc.Name == "" || track.stsz.Name == "" || track.chunkOffset.Name == "" {
+		return nil, fmt.Errorf("chapter track (id: %d) does not have sample table", track.id)
+	}
+	timeToSample, err := readTableEntries(rs, track.stts, 2)
+	if err != nil {
+		return nil, err
+	}
+	sampleCount := int64(0)
+	for _, entry := range timeToSample {
+		sampleCount += entry[0] /* sample count */
+	}
+	offsets, sizes, err := readSampleOffsets(rs, track.stsc, track.stsz, track.chunkOffset, sampleCount)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters := []chapter.Chapter{}
+	start := int64(0)
+	entryIndex, sampleIndexInEntry := 0, int64(0)
+	for i, offset := range offsets {
+		for sampleIndexInEntry == timeToSample[entryIndex][0] /* sample count */ {
+			entryIndex, sampleIndexInEntry = entryIndex+1, 0
+		}
+		if offset < 0 || offset+sizes[i] > size {
+			return nil, fmt.Errorf("chapter track (id: %d) sample %d exceeds the file", track.id, i+1)
+		}
+		_, err = rs.Seek(offset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		sample, err := binary.Read(rs, uint(sizes[i]))
+		if err != nil {
+			return nil, err
+		}
+		title, err := chapter.DecodeTextSample(sample)
+		if err != nil {
+			return nil, fmt.Errorf("chapter track (id: %d) sample %d %w", track.id, i+1, err)
+		}
+		chapters = append(chapters, chapter.Chapter{
+			Start: time.Duration(start/track.timescale)*time.Second + time.Duration(start%track.timescale)*time.Second/time.Duration(track.timescale),
+			Title: title,
+		})
+		start += timeToSample[entryIndex][1] /* sample duration */
+		sampleIndexInEntry++
+	}
+	return chapters, nil
+}
+
+// WriteChapters replaces the Nero chapters (`.moov.udta.chpl`), or removes it if chapters is empty.
+// QuickTime chapter track is not changed.
+func (r *readWriter) WriteChapters(dest, tmpDest *os.File, chapters []chapter.Chapter) error {
+	if len(chapters) == 0 {
+		return r.writeUdtaBoxes(dest, tmpDest, nil, []string{"chpl"})
+	}
+	data, err := chapter.EncodeNero(chapters)
+	if err != nil {
+		return err
+	}
+	return r.writeUdtaBoxes(dest, tmpDest, map[string][]byte{"chpl": data}, nil)
+}
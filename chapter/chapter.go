@@ -0,0 +1,201 @@
+package chapter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var (
+	ErrInvalidLength   = errors.New("invalid length")
+	ErrTooManyChapters = errors.New("too many chapters (max: 255)")
+	ErrTitleTooLong    = errors.New("too long chapter title (max: 255 bytes)")
+	ErrNoChapters      = errors.New("no chapters")
+)
+
+// Chapter is the chapter starting at Start.
+type Chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// String formats the chapter as the line of the chapter list ("HH:MM:SS.mmm Title").
+func (c Chapter) String() string {
+	ms := c.Start.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d %s", ms/3600000, ms/60000%60, ms/1000%60, ms%1000, c.Title)
+}
+
+// Chapters is the chapter lists of the file.
+type Chapters struct {
+	// Nero chapters (`.moov.udta.chpl`)
+	Nero []Chapter
+	// QuickTime chapter track (text track referenced by `tref` `chap`)
+	Track []Chapter
+}
+
+// neroTimescale is the time scale of the start time of Nero chapters (100 nanoseconds).
+const neroTimescale = 10_000_000
+
+// DecodeNero decodes the data of `chpl` box.
+// Data is "<version (8 bit)><flags (24 bit)>[<reserved (32 bit)> if version 1]<count (8 bit)>"
+// followed by "<start time (64 bit)><title size (8 bit)><title (UTF-8)>" per chapter.
+func DecodeNero(data []byte) ([]Chapter, error) {
+	if len(data) < 5 {
+		return nil, ErrInvalidLength
+	}
+	offset := 4 /* version, flags */
+	if data[0] == 1 {
+		offset += 4 /* reserved */
+	}
+	if len(data) < offset+1 {
+		return nil, ErrInvalidLength
+	}
+	count := int(data[offset])
+	offset++
+
+	chapters := make([]Chapter, 0, count)
+	for range count {
+		if len(data) < offset+9 {
+			return nil, ErrInvalidLength
+		}
+		start, err := binary.BigEdian.ReadI64(bytes.NewReader(data[offset : offset+8]))
+		if err != nil {
+			return nil, err
+		}
+		size := int(data[offset+8])
+		offset += 9
+		if len(data) < offset+size {
+			return nil, ErrInvalidLength
+		}
+		chapters = append(chapters, Chapter{
+			Start: time.Duration(start) * (time.Second / neroTimescale),
+			Title: string(data[offset : offset+size]),
+		})
+		offset += size
+	}
+	return chapters, nil
+}
+
+// EncodeNero encodes the chapters to the data of `chpl` box (version 1).
+func EncodeNero(chapters []Chapter) ([]byte, error) {
+	if len(chapters) > 0xFF {
+		return nil, ErrTooManyChapters
+	}
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{0x1, 0x0, 0x0, 0x0} /* version, flags */)
+	buf.Write(bytes.Repeat([]byte{0x0}, 4) /* reserved */)
+	buf.WriteByte(byte(len(chapters)))
+	for _, chapter := range chapters {
+		if len(chapter.Title) > 0xFF {
+			return nil, fmt.Errorf("%w (\"%s\")", ErrTitleTooLong, chapter.Title)
+		}
+		buf.Write(binary.BigEdian.BytesI64(int64(chapter.Start / (time.Second / neroTimescale))))
+		buf.WriteByte(byte(len(chapter.Title)))
+		buf.WriteString(chapter.Title)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeTextSample decodes the title in the sample of QuickTime text track.
+// Sample is "<text size (16 bit)><text>" followed by optional atoms,
+// and the text is UTF-16 if it starts with BOM, otherwise UTF-8.
+// https://developer.apple.com/documentation/quicktime-file-format/text_sample_data
+func DecodeTextSample(data []byte) (string, error) {
+	if len(data) < 2 {
+		return "", ErrInvalidLength
+	}
+	size16, err := binary.BigEdian.ReadI16(bytes.NewReader(data[:2]))
+	if err != nil {
+		return "", err
+	}
+	size := int(uint16(size16))
+	if len(data) < 2+size {
+		return "", ErrInvalidLength
+	}
+	text := data[2 : 2+size]
+
+	littleEndian := false
+	switch {
+	case bytes.HasPrefix(text, []byte{0xFE, 0xFF}):
+	case bytes.HasPrefix(text, []byte{0xFF, 0xFE}):
+		littleEndian = true
+	default:
+		return string(text), nil
+	}
+	r := bytes.NewReader(text[2:])
+	units := make([]uint16, 0, len(text)/2)
+	for r.Len() >= 2 {
+		unit, err := binary.BigEdian.ReadI16(r)
+		if err != nil {
+			return "", err
+		}
+		if littleEndian {
+			unit = int16(bits.ReverseBytes16(uint16(unit)))
+		}
+		units = append(units, uint16(unit))
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// Parse parses the chapter list that has a chapter per line ("HH:MM:SS.mmm Title").
+// Empty lines are skipped, and chapters must be in order of the start time.
+// ErrNoChapters is returned if there is no chapter (remove the chapters with WriteChapters of no chapters instead).
+func Parse(r io.Reader) ([]Chapter, error) {
+	chapters := []Chapter{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		timeStr, title, _ := strings.Cut(text, " ")
+		start, err := parseTime(timeStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w (line %d)", err, line)
+		}
+		if len(chapters) != 0 && start < chapters[len(chapters)-1].Start {
+			return nil, fmt.Errorf("chapters are not in order of the start time (line %d)", line)
+		}
+		chapters = append(chapters, Chapter{start, strings.TrimSpace(title)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, ErrNoChapters
+	}
+	return chapters, nil
+}
+
+// parseTime parses "HH:MM:SS.mmm" (milliseconds can be omitted).
+func parseTime(str string) (time.Duration, error) {
+	hms := strings.Split(str, ":")
+	if len(hms) != 3 {
+		return 0, fmt.Errorf("invalid time (\"%s\")", str)
+	}
+	secondsStr, millisecondsStr, _ := strings.Cut(hms[2], ".")
+	if len(millisecondsStr) > 3 {
+		return 0, fmt.Errorf("invalid time (\"%s\")", str)
+	}
+	millisecondsStr += strings.Repeat("0", 3-len(millisecondsStr))
+
+	duration := time.Duration(0)
+	for i, s := range []string{hms[0], hms[1], secondsStr, millisecondsStr} {
+		value, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || (i == 1 || i == 2) && value >= 60 {
+			return 0, fmt.Errorf("invalid time (\"%s\")", str)
+		}
+		unit := []time.Duration{time.Hour, time.Minute, time.Second, time.Millisecond}[i]
+		duration += time.Duration(value) * unit
+	}
+	return duration, nil
+}
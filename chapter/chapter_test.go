@@ -0,0 +1,149 @@
+package chapter
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testChapters = []Chapter{
+	{0, "Opening"},
+	{time.Minute + 2500*time.Millisecond, "Second part"},
+	{time.Hour, "Final, with spaces"},
+}
+
+func TestNeroRoundTrip(t *testing.T) {
+	data, err := EncodeNero(testChapters)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	got, err := DecodeNero(data)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if !slices.Equal(got, testChapters) {
+		t.Errorf("chapters = %v, want %v", got, testChapters)
+	}
+}
+
+func TestDecodeNero(t *testing.T) {
+	chapter := slices.Concat([]byte{0, 0, 0, 0, 0x01, 0x31, 0x2D, 0x00 /* 2 s */}, []byte{2}, []byte("Hi"))
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []Chapter
+		wantErr error
+	}{
+		{"version 0", slices.Concat([]byte{0, 0, 0, 0}, []byte{1}, chapter), []Chapter{{2 * time.Second, "Hi"}}, nil},
+		{"version 1", slices.Concat([]byte{1, 0, 0, 0}, []byte{0, 0, 0, 0}, []byte{1}, chapter), []Chapter{{2 * time.Second, "Hi"}}, nil},
+		{"no count", []byte{1, 0, 0, 0, 0, 0, 0, 0}, nil, ErrInvalidLength},
+		{"count exceeds data", slices.Concat([]byte{0, 0, 0, 0}, []byte{2}, chapter), nil, ErrInvalidLength},
+		{"title exceeds data", slices.Concat([]byte{0, 0, 0, 0}, []byte{1}, chapter[:len(chapter)-1]), nil, ErrInvalidLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeNero(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("chapters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeNeroError(t *testing.T) {
+	tests := []struct {
+		name     string
+		chapters []Chapter
+		wantErr  error
+	}{
+		{"too many chapters", make([]Chapter, 256), ErrTooManyChapters},
+		{"too long title", []Chapter{{0, strings.Repeat("a", 256)}}, ErrTitleTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := EncodeNero(tt.chapters); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeTextSample(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		{"UTF-8", slices.Concat([]byte{0, 6}, []byte("Chäp1")), "Chäp1", nil},
+		{"UTF-8 followed by atoms", slices.Concat([]byte{0, 2}, []byte("Hi"), []byte{0, 0, 0, 12}, []byte("encd"), []byte{0, 0, 1, 0}), "Hi", nil},
+		{"UTF-16 big-endian", []byte{0, 6, 0xFE, 0xFF, 0, 'H', 0, 'i'}, "Hi", nil},
+		{"UTF-16 little-endian", []byte{0, 6, 0xFF, 0xFE, 'H', 0, 'i', 0}, "Hi", nil},
+		{"no size", []byte{0}, "", ErrInvalidLength},
+		{"size exceeds data", []byte{0, 3, 'H', 'i'}, "", ErrInvalidLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeTextSample(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	list := "00:00:00.000 Opening\n\n  00:01:02.5 Second part \n01:00:00 Final, with spaces\n"
+	got, err := Parse(strings.NewReader(list))
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if !slices.Equal(got, testChapters) {
+		t.Errorf("chapters = %v, want %v", got, testChapters)
+	}
+
+	// chapter list is printed in the format that Parse reads
+	printed := &bytes.Buffer{}
+	for _, chapter := range got {
+		printed.WriteString(chapter.String() + "\n")
+	}
+	reparsed, err := Parse(printed)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if !slices.Equal(reparsed, testChapters) {
+		t.Errorf("reparsed = %v, want %v", reparsed, testChapters)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		wantErr error // any error if nil
+	}{
+		{"empty", "", ErrNoChapters},
+		{"blank lines only", "\n  \n", ErrNoChapters},
+		{"invalid time", "1:00 Title\n", nil},
+		{"minutes over 59", "00:60:00.000 Title\n", nil},
+		{"more than milliseconds", "00:00:00.0001 Title\n", nil},
+		{"not in order", "00:01:00 B\n00:00:30 A\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.list))
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
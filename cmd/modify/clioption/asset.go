@@ -0,0 +1,42 @@
+package clioption
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tingtt/qtffilst/udta"
+)
+
+func loadAssetChanges(changeDatas, removeIds []string) (assets *udta.Assets, deleteIds []string, err error) {
+	assets = &udta.Assets{}
+	for _, changeDataStr := range changeDatas {
+		name, value, err := decodeChangeData(changeDataStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CLI option `--3gpp` %w", err)
+		}
+		name, languageStr, hasLanguage := strings.Cut(name, "@")
+		language := udta.LanguageUndetermined
+		if hasLanguage {
+			language, err = udta.ParseLanguage(languageStr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("CLI option `--3gpp` %w", err)
+			}
+		}
+		id, ok := udta.ResolveAssetId(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("CLI option `--3gpp` invalid 3GPP asset id or name (\"%s\")", name)
+		}
+		err = assets.Set(id, value, language)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CLI option `--3gpp` %w", err)
+		}
+	}
+	for _, name := range removeIds {
+		id, ok := udta.ResolveAssetId(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("CLI option `--3gpp-rm` invalid 3GPP asset id or name (\"%s\")", name)
+		}
+		deleteIds = append(deleteIds, id)
+	}
+	return assets, deleteIds, nil
+}
@@ -3,7 +3,6 @@ package clioption
 import (
 	"errors"
 	"fmt"
-	"slices"
 	"strings"
 
 	"github.com/tingtt/iterutil"
@@ -14,10 +13,14 @@ func loadChanges(changeDatas, removeIds []string) (itemList *ilst.ItemList, dele
 	newItemList := new(ilst.ItemList)
 
 	for _, changeDataStr := range changeDatas {
-		id, value, err := decodeChangeData(changeDataStr)
+		name, value, err := decodeChangeData(changeDataStr)
 		if err != nil {
 			return nil, nil, fmt.Errorf("CLI option `--data`,`-d` %w", err)
 		}
+		id, ok := ilst.ResolveId(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("CLI option `--data`,`-d` invalid ItemList id or name (\"%s\")", name)
+		}
 
 		for _, v := range iterutil.FilterKey(ilst.IterateFieldWriters(newItemList), id) {
 			decodedValue, err := v.GetDecorder().Decode(value)
@@ -32,23 +35,21 @@ func loadChanges(changeDatas, removeIds []string) (itemList *ilst.ItemList, dele
 		}
 	}
 
-	for _, id := range removeIds {
-		if !validItemListBoxId(id) {
-			return nil, nil, fmt.Errorf("CLI option `--rm`,`-r` invalid ItemList id")
+	for _, name := range removeIds {
+		id, ok := ilst.ResolveId(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("CLI option `--rm`,`-r` invalid ItemList id or name (\"%s\")", name)
 		}
+		deleteIds = append(deleteIds, id)
 	}
 
-	return newItemList, removeIds, nil
+	return newItemList, deleteIds, nil
 }
 
 func decodeChangeData(str string) (id, value string, err error) {
-	l := strings.Split(str, "=")
+	l := strings.SplitN(str, "=", 2)
 	if len(l) != 2 {
 		return "", "", errors.New("invalid format")
 	}
 	return l[0], strings.Trim(l[1], "\""), nil
 }
-
-func validItemListBoxId(id string) bool {
-	return len(slices.Collect(iterutil.Filter(ilst.Ids(), id))) == 1
-}
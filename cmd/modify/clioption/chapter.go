@@ -0,0 +1,16 @@
+package clioption
+
+import (
+	"os"
+
+	"github.com/tingtt/qtffilst/chapter"
+)
+
+func loadChapters(chaptersPath string) ([]chapter.Chapter, error) {
+	f, err := os.Open(chaptersPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return chapter.Parse(f)
+}
@@ -0,0 +1,24 @@
+package clioption
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tingtt/qtffilst/ilst"
+)
+
+func loadCoverImages(imagePaths []string) ([]ilst.Image, error) {
+	images := make([]ilst.Image, 0, len(imagePaths))
+	for _, imagePath := range imagePaths {
+		data, err := os.ReadFile(imagePath)
+		if err != nil {
+			return nil, err
+		}
+		image, err := ilst.NewImage(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, imagePath)
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
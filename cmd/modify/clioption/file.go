@@ -45,3 +45,15 @@ func createDestFile(destFilePath, tmpDestFilePath *string) (dest *os.File, tmpDe
 	}
 	return dest, tmpDest, tmpDest2, nil
 }
+
+func createStageTmpFiles(tmpDestFilePath string) (tmpDest3, tmpDest4 *os.File, err error) {
+	tmpDest3, err = os.Create(tmpDestFilePath + "3.m4a")
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpDest4, err = os.Create(tmpDestFilePath + "4.m4a")
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpDest3, tmpDest4, nil
+}
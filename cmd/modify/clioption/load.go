@@ -1,11 +1,18 @@
 package clioption
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/spf13/pflag"
+	"github.com/tingtt/qtffilst"
+	"github.com/tingtt/qtffilst/chapter"
 	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/mdta"
+	"github.com/tingtt/qtffilst/udta"
 )
 
 type CLIOption struct {
@@ -14,8 +21,45 @@ type CLIOption struct {
 	TmpDest       *os.File
 	TmpDest2      *os.File
 	KeepTmpFile   bool
+	DryRun        bool
+	Verify        bool
 	ItemList      *ilst.ItemList
 	DeleteItemIds []string
+	CopyFrom      *f
+	CopyOption    qtffilst.CopyOption
+	// Images to append to the current cover art
+	AppendCoverImages []ilst.Image
+	// Convert ReplayGain track gain/peak to SoundCheck (iTunNORM)
+	SoundCheckFromReplayGain bool
+	// Convert SoundCheck (iTunNORM) to ReplayGain track gain/peak
+	ReplayGainFromSoundCheck bool
+	// Metadata with keys (`.moov.meta`) to write
+	Metadata           mdta.Metadata
+	DeleteMetadataKeys []string
+	// Classic user data text atoms (`.moov.udta.©xxx`) to write
+	UserData          udta.UserData
+	DeleteUserDataIds []string
+	// Mirror ItemList text changes to the classic user data text atoms
+	MirrorUserData bool
+	// 3GPP asset information boxes (`.moov.udta.titl`, ...) to write
+	Assets         *udta.Assets
+	DeleteAssetIds []string
+	// XMP packet to write (nil if not changed)
+	XMPPacket []byte
+	RemoveXMP bool
+	// Remove the ID3v2 tag (`.moov.udta.meta.ID32`)
+	RemoveID32 bool
+	// Regenerate the ID3v2 tag (`.moov.udta.meta.ID32`) from the written ItemList
+	ID32FromItemList bool
+	// Nero chapters (`.moov.udta.chpl`) to write (nil if not changed)
+	Chapters       []chapter.Chapter
+	RemoveChapters bool
+	// Move `.moov` ahead of `.mdat` with `free` box of the padding bytes after it
+	FastStart        bool
+	FastStartPadding int32
+	// Dest files of the write stages before the last stage (nil if only ItemList changes)
+	TmpDest3 *os.File
+	TmpDest4 *os.File
 }
 
 type f struct {
@@ -29,8 +73,35 @@ func Load() (CLIOption, error) {
 	destPath := pflag.StringP("out", "o", "", "dest file path")
 	tmpDestPath := pflag.String("tmp", "", "tmp dest file path")
 	keepTmpFile := pflag.Bool("keep", false, "keep tmp dest file")
-	changeDatas := pflag.StringSliceP("data", "d", nil, "Write QTFF ItemList tag.\n\tformat: <id>=<value>")
-	removeIds := pflag.StringSliceP("rm", "r", nil, "")
+	dryRun := pflag.Bool("dry-run", false, "show changes without writing")
+	verify := pflag.Bool("verify", false, "verify dest file after writing")
+	changeDatas := pflag.StringSliceP("data", "d", nil, "Write QTFF ItemList tag.\n\tformat: <id or name>=<value>")
+	removeIds := pflag.StringSliceP("rm", "r", nil, "Remove QTFF ItemList tag.\n\tformat: <id or name>")
+	copyFromPath := pflag.String("copy-from", "", "copy QTFF ItemList tags from the file")
+	copyIncludeIds := pflag.StringSlice("copy-include", nil, "copy only the tags of these ids or names")
+	copyExcludeIds := pflag.StringSlice("copy-exclude", nil, "do not copy the tags of these ids or names")
+	copyReplace := pflag.Bool("copy-replace", false, "remove tags that do not exist in the --copy-from file")
+	coverPaths := pflag.StringSlice("cover", nil, "replace cover art with the image files (JPEG/PNG)")
+	coverAppendPaths := pflag.StringSlice("cover-append", nil, "append the image files (JPEG/PNG) to cover art")
+	coverClear := pflag.Bool("cover-clear", false, "remove cover art")
+	soundCheckFromReplayGain := pflag.Bool("soundcheck-from-replaygain", false, "write iTunNORM converted from ReplayGain track gain/peak")
+	replayGainFromSoundCheck := pflag.Bool("replaygain-from-soundcheck", false, "write ReplayGain track gain/peak converted from iTunNORM")
+	metadataDatas := pflag.StringArray("mdta", nil, "Write QuickTime metadata with keys (.moov.meta).\n\tformat: <key>=<text> (key accepts \"make\" for \"com.apple.quicktime.make\")")
+	metadataRemoveKeys := pflag.StringArray("mdta-rm", nil, "Remove QuickTime metadata with keys (.moov.meta).\n\tformat: <key>")
+	userDataDatas := pflag.StringArray("udta", nil, "Write classic QuickTime user data text atom (.moov.udta.©xxx).\n\tformat: <id or name>[@<language>]=<text> (language: ISO 639-2/T code or Macintosh language code, default: und)")
+	userDataRemoveIds := pflag.StringArray("udta-rm", nil, "Remove classic QuickTime user data text atom (.moov.udta.©xxx).\n\tformat: <id or name>")
+	mirrorUserData := pflag.Bool("mirror-udta", false, "mirror text changes of ItemList to classic QuickTime user data text atoms")
+	assetDatas := pflag.StringArray("3gpp", nil, "Write 3GPP asset information box (.moov.udta.titl, ...).\n\tformat: <id or name>[@<language>]=<value> (language: ISO 639-2/T code, default: und)")
+	assetRemoveIds := pflag.StringArray("3gpp-rm", nil, "Remove 3GPP asset information box (.moov.udta.titl, ...).\n\tformat: <id or name>")
+	xmpPath := pflag.String("xmp", "", "replace XMP packet (uuid box) with the file")
+	xmpRemove := pflag.Bool("xmp-rm", false, "remove XMP packet (uuid box)")
+	id32Remove := pflag.Bool("id32-rm", false, "remove ID3v2 tag (.moov.udta.meta.ID32)")
+	id32FromItemList := pflag.Bool("id32-from-ilst", false, "regenerate ID3v2 tag (.moov.udta.meta.ID32) from the written ItemList")
+	chaptersPath := pflag.String("chapters", "", "replace Nero chapters (.moov.udta.chpl) with the chapter list file.\n\tformat: a chapter per line (\"HH:MM:SS.mmm Title\")")
+	chaptersRemove := pflag.Bool("chapters-rm", false, "remove Nero chapters (.moov.udta.chpl)")
+	fastStart := pflag.Bool("faststart", false, "move .moov ahead of .mdat for progressive playback")
+	fastStartPadding := pflag.Int32("faststart-padding", 0, "size (bytes) of free box that replaces free boxes after .moov by --faststart")
+	stripPersonal := pflag.Bool("strip-personal", false, "remove personally identifying purchase tags ("+strings.Join(ilst.PersonalIds, ",")+")")
 
 	// Options for developer
 	debugLogEnable := pflag.Bool("debug", false, "Enable debug logs")
@@ -41,19 +112,155 @@ func Load() (CLIOption, error) {
 	if err != nil {
 		return CLIOption{}, err
 	}
-	dest, tmpDest, tmpDest2, err := createDestFile(destPath, tmpDestPath)
+
+	itemList, deleteIds, err := loadChanges(*changeDatas, *removeIds)
 	if err != nil {
 		return CLIOption{}, err
 	}
 
-	itemList, deleteIds, err := loadChanges(*changeDatas, *removeIds)
+	metadata, deleteMetadataKeys, err := loadMetadataChanges(*metadataDatas, *metadataRemoveKeys)
+	if err != nil {
+		return CLIOption{}, err
+	}
+
+	userData, deleteUserDataIds, err := loadUserDataChanges(*userDataDatas, *userDataRemoveIds)
+	if err != nil {
+		return CLIOption{}, err
+	}
+
+	assets, deleteAssetIds, err := loadAssetChanges(*assetDatas, *assetRemoveIds)
 	if err != nil {
 		return CLIOption{}, err
 	}
 
+	var xmpPacket []byte
+	if *xmpPath != "" {
+		if *xmpRemove {
+			return CLIOption{}, errors.New("CLI option `--xmp` cannot be used with `--xmp-rm`")
+		}
+		xmpPacket, err = os.ReadFile(*xmpPath)
+		if err != nil {
+			return CLIOption{}, fmt.Errorf("CLI option `--xmp` %w", err)
+		}
+	}
+
+	var chapters []chapter.Chapter
+	if *chaptersPath != "" {
+		if *chaptersRemove {
+			return CLIOption{}, errors.New("CLI option `--chapters` cannot be used with `--chapters-rm`")
+		}
+		chapters, err = loadChapters(*chaptersPath)
+		if err != nil {
+			return CLIOption{}, fmt.Errorf("CLI option `--chapters` %w", err)
+		}
+	}
+
+	if *id32Remove && *id32FromItemList {
+		return CLIOption{}, errors.New("CLI option `--id32-rm` cannot be used with `--id32-from-ilst`")
+	}
+
+	if *fastStartPadding != 0 && !*fastStart {
+		return CLIOption{}, errors.New("CLI option `--faststart-padding` requires `--faststart`")
+	}
+	if *fastStartPadding < 0 {
+		return CLIOption{}, errors.New("CLI option `--faststart-padding` must not be negative")
+	}
+
+	if *soundCheckFromReplayGain && *replayGainFromSoundCheck {
+		return CLIOption{}, errors.New("CLI option `--soundcheck-from-replaygain` cannot be used with `--replaygain-from-soundcheck`")
+	}
+
+	if *stripPersonal {
+		deleteIds = append(deleteIds, ilst.PersonalIds...)
+	}
+
+	if *coverClear {
+		if len(*coverPaths) != 0 || len(*coverAppendPaths) != 0 {
+			return CLIOption{}, errors.New("CLI option `--cover-clear` cannot be used with `--cover`,`--cover-append`")
+		}
+		deleteIds = append(deleteIds, "covr")
+	}
+	coverImages, err := loadCoverImages(*coverPaths)
+	if err != nil {
+		return CLIOption{}, fmt.Errorf("CLI option `--cover` %w", err)
+	}
+	appendCoverImages, err := loadCoverImages(*coverAppendPaths)
+	if err != nil {
+		return CLIOption{}, fmt.Errorf("CLI option `--cover-append` %w", err)
+	}
+	if len(coverImages) != 0 {
+		itemList.CoverArt = &ilst.CoverArt{Images: append(coverImages, appendCoverImages...)}
+		appendCoverImages = nil
+	}
+
+	var copyFrom *f
+	if *copyFromPath != "" {
+		file, err := loadFile(copyFromPath)
+		if err != nil {
+			return CLIOption{}, err
+		}
+		copyFrom = &file
+	}
+
+	var dest, tmpDest, tmpDest2, tmpDest3, tmpDest4 *os.File
+	if !*dryRun {
+		dest, tmpDest, tmpDest2, err = createDestFile(destPath, tmpDestPath)
+		if err != nil {
+			return CLIOption{}, err
+		}
+		if len(metadata) != 0 || len(deleteMetadataKeys) != 0 ||
+			len(userData) != 0 || len(deleteUserDataIds) != 0 || *mirrorUserData ||
+			len(*assetDatas) != 0 || len(deleteAssetIds) != 0 ||
+			xmpPacket != nil || *xmpRemove ||
+			*id32Remove || *id32FromItemList || copyFrom != nil ||
+			chapters != nil || *chaptersRemove ||
+			*fastStart {
+			tmpDest3, tmpDest4, err = createStageTmpFiles(*tmpDestPath)
+			if err != nil {
+				return CLIOption{}, err
+			}
+		}
+	}
+
 	if *debugLogEnable {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
 	}
 
-	return CLIOption{file, dest, tmpDest, tmpDest2, *keepTmpFile, itemList, deleteIds}, nil
+	return CLIOption{
+		File:          file,
+		Dest:          dest,
+		TmpDest:       tmpDest,
+		TmpDest2:      tmpDest2,
+		KeepTmpFile:   *keepTmpFile,
+		DryRun:        *dryRun,
+		Verify:        *verify,
+		ItemList:      itemList,
+		DeleteItemIds: deleteIds,
+		CopyFrom:      copyFrom,
+		CopyOption: qtffilst.CopyOption{
+			IncludeIds: *copyIncludeIds,
+			ExcludeIds: *copyExcludeIds,
+			Replace:    *copyReplace,
+		},
+		AppendCoverImages:        appendCoverImages,
+		SoundCheckFromReplayGain: *soundCheckFromReplayGain,
+		ReplayGainFromSoundCheck: *replayGainFromSoundCheck,
+		Metadata:                 metadata,
+		DeleteMetadataKeys:       deleteMetadataKeys,
+		UserData:                 userData,
+		DeleteUserDataIds:        deleteUserDataIds,
+		MirrorUserData:           *mirrorUserData,
+		Assets:                   assets,
+		DeleteAssetIds:           deleteAssetIds,
+		XMPPacket:                xmpPacket,
+		RemoveXMP:                *xmpRemove,
+		RemoveID32:               *id32Remove,
+		ID32FromItemList:         *id32FromItemList,
+		Chapters:                 chapters,
+		RemoveChapters:           *chaptersRemove,
+		FastStart:                *fastStart,
+		FastStartPadding:         *fastStartPadding,
+		TmpDest3:                 tmpDest3,
+		TmpDest4:                 tmpDest4,
+	}, nil
 }
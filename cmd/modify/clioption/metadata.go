@@ -0,0 +1,22 @@
+package clioption
+
+import (
+	"fmt"
+
+	"github.com/tingtt/qtffilst/mdta"
+)
+
+func loadMetadataChanges(changeDatas, removeKeys []string) (metadata mdta.Metadata, deleteKeys []string, err error) {
+	metadata = mdta.Metadata{}
+	for _, changeDataStr := range changeDatas {
+		name, value, err := decodeChangeData(changeDataStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CLI option `--mdta` %w", err)
+		}
+		metadata[mdta.ResolveKey(name)] = []mdta.Value{mdta.NewText(value)}
+	}
+	for _, name := range removeKeys {
+		deleteKeys = append(deleteKeys, mdta.ResolveKey(name))
+	}
+	return metadata, deleteKeys, nil
+}
@@ -0,0 +1,63 @@
+package clioption
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/udta"
+)
+
+func loadUserDataChanges(changeDatas, removeIds []string) (userData udta.UserData, deleteIds []string, err error) {
+	userData = udta.UserData{}
+	for _, changeDataStr := range changeDatas {
+		name, value, err := decodeChangeData(changeDataStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CLI option `--udta` %w", err)
+		}
+		name, languageStr, hasLanguage := strings.Cut(name, "@")
+		language := udta.LanguageUndetermined
+		if hasLanguage {
+			language, err = udta.ParseLanguage(languageStr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("CLI option `--udta` %w", err)
+			}
+		}
+		id, err := resolveUserDataId(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CLI option `--udta` %w", err)
+		}
+
+		texts := userData[id]
+		for i, text := range texts {
+			if text.Language == language {
+				texts = append(texts[:i], texts[i+1:]...)
+				break
+			}
+		}
+		userData[id] = append(texts, udta.Text{Language: language, Text: value})
+	}
+	for _, name := range removeIds {
+		id, err := resolveUserDataId(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CLI option `--udta-rm` %w", err)
+		}
+		deleteIds = append(deleteIds, id)
+	}
+	return userData, deleteIds, nil
+}
+
+// resolveUserDataId accepts the names of ItemList (e.g. "title") and the classic user data text atom ids (e.g. "©req").
+func resolveUserDataId(name string) (string, error) {
+	id, ok := ilst.ResolveId(name)
+	if !ok {
+		id = name
+		if strings.HasPrefix(id, "©") {
+			id = "(c)" + strings.TrimPrefix(id, "©")
+		}
+	}
+	if !udta.TextAtom(id) || len(strings.TrimPrefix(id, "(c)")) != 3 {
+		return "", fmt.Errorf("invalid user data text atom id or name (\"%s\")", name)
+	}
+	return id, nil
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
+	"reflect"
+	"slices"
 
 	"github.com/tingtt/qtffilst"
+	"github.com/tingtt/qtffilst/chapter"
 	"github.com/tingtt/qtffilst/cmd/modify/clioption"
+	"github.com/tingtt/qtffilst/id3"
+	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/mdta"
+	"github.com/tingtt/qtffilst/udta"
 )
 
 func main() {
@@ -27,18 +38,417 @@ func run() error {
 		return err
 	}
 
-	err = r.Write(
-		cliOption.Dest, cliOption.TmpDest, cliOption.TmpDest2,
-		*cliOption.ItemList, cliOption.DeleteItemIds,
+	itemList, deleteIds := *cliOption.ItemList, cliOption.DeleteItemIds
+	var (
+		rawItems     []qtffilst.RawItem
+		deleteRawIds []string
 	)
+	if cliOption.CopyFrom != nil {
+		src, err := qtffilst.NewReader(cliOption.CopyFrom)
+		if err != nil {
+			return err
+		}
+		itemList, deleteIds, err = loadCopyItems(r, src, cliOption)
+		if err != nil {
+			return err
+		}
+		rawItems, deleteRawIds, err = loadCopyRawItems(r, src, cliOption)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(cliOption.AppendCoverImages) != 0 {
+		if itemList.CoverArt == nil {
+			current, err := r.Read()
+			if err != nil {
+				return err
+			}
+			itemList.CoverArt = current.CoverArt
+		}
+		if itemList.CoverArt == nil {
+			itemList.CoverArt = &ilst.CoverArt{}
+		}
+		itemList.CoverArt.Images = append(itemList.CoverArt.Images, cliOption.AppendCoverImages...)
+		deleteIds = slices.DeleteFunc(deleteIds, func(id string) bool { return id == "covr" })
+	}
+
+	if cliOption.SoundCheckFromReplayGain || cliOption.ReplayGainFromSoundCheck {
+		err = convertLoudness(r, &itemList, cliOption.SoundCheckFromReplayGain)
+		if err != nil {
+			return err
+		}
+	}
+
+	userData, deleteUserDataIds, err := loadUserDataChanges(r, cliOption, itemList, deleteIds)
 	if err != nil {
 		return err
 	}
 
+	if cliOption.DryRun {
+		plan, err := r.Plan(itemList, deleteIds)
+		if err != nil {
+			return err
+		}
+		printPlan(plan)
+		printRawItemChanges(rawItems, deleteRawIds)
+		printMetadataChanges(cliOption.Metadata, cliOption.DeleteMetadataKeys)
+		printUserDataChanges(userData, deleteUserDataIds)
+		printAssetChanges(*cliOption.Assets, cliOption.DeleteAssetIds)
+		printXMPChanges(cliOption.XMPPacket, cliOption.RemoveXMP)
+		printID32Changes(cliOption.RemoveID32, cliOption.ID32FromItemList)
+		printChapterChanges(cliOption.Chapters, cliOption.RemoveChapters)
+		printFastStartChanges(cliOption.FastStart, cliOption.FastStartPadding)
+		return nil
+	}
+
+	stages := []writeStage{
+		func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.Write(dest, tmpDest, cliOption.TmpDest2, itemList, deleteIds)
+		},
+	}
+	if len(rawItems) != 0 || len(deleteRawIds) != 0 {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.WriteRawItems(dest, tmpDest, rawItems, deleteRawIds)
+		})
+	}
+	if cliOption.RemoveID32 || cliOption.ID32FromItemList {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			if cliOption.RemoveID32 {
+				return r.WriteID32(dest, tmpDest, nil)
+			}
+			id32, err := id32FromItemList(r)
+			if err != nil {
+				return err
+			}
+			return r.WriteID32(dest, tmpDest, id32)
+		})
+	}
+	if len(cliOption.Metadata) != 0 || len(cliOption.DeleteMetadataKeys) != 0 {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.WriteMetadata(dest, tmpDest, cliOption.Metadata, cliOption.DeleteMetadataKeys)
+		})
+	}
+	if len(userData) != 0 || len(deleteUserDataIds) != 0 {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.WriteUserData(dest, tmpDest, userData, deleteUserDataIds)
+		})
+	}
+	if *cliOption.Assets != (udta.Assets{}) || len(cliOption.DeleteAssetIds) != 0 {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.WriteAssets(dest, tmpDest, *cliOption.Assets, cliOption.DeleteAssetIds)
+		})
+	}
+	if cliOption.XMPPacket != nil || cliOption.RemoveXMP {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.WriteXMP(dest, tmpDest, cliOption.XMPPacket)
+		})
+	}
+	if cliOption.Chapters != nil || cliOption.RemoveChapters {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.WriteChapters(dest, tmpDest, cliOption.Chapters)
+		})
+	}
+	if cliOption.FastStart {
+		stages = append(stages, func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error {
+			return r.WriteFastStart(dest, tmpDest, cliOption.FastStartPadding)
+		})
+	}
+	err = write(r, cliOption, stages)
+	if err != nil {
+		return err
+	}
+
+	if cliOption.Verify {
+		err = r.Verify(cliOption.Dest, itemList, deleteIds)
+		if err != nil {
+			return err
+		}
+		slog.Info("verified", slog.String("path", cliOption.Dest.Name()))
+	}
+
 	if !cliOption.KeepTmpFile {
 		os.Remove(cliOption.TmpDest.Name())
 		os.Remove(cliOption.TmpDest2.Name())
+		if cliOption.TmpDest3 != nil {
+			os.Remove(cliOption.TmpDest3.Name())
+			os.Remove(cliOption.TmpDest4.Name())
+		}
+	}
+
+	return nil
+}
+
+// writeStage writes the changes of r to dest.
+type writeStage func(r qtffilst.ReadWriter, dest, tmpDest *os.File) error
+
+// write writes the changes through the stages (ItemList, raw items, ID32, Metadata, UserData, Assets, XMP, Chapters, FastStart).
+// Each stage except the last one writes to the tmp file, which is read by the next stage.
+func write(r qtffilst.ReadWriter, cliOption clioption.CLIOption, stages []writeStage) error {
+	stageDests := []*os.File{cliOption.TmpDest3, cliOption.TmpDest4}
+	for i, stage := range stages {
+		dest := cliOption.Dest
+		if i != len(stages)-1 {
+			dest = stageDests[i%2]
+		}
+		if i != 0 {
+			for _, f := range []*os.File{dest, cliOption.TmpDest, cliOption.TmpDest2} {
+				err := reset(f)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err := stage(r, dest, cliOption.TmpDest)
+		if err != nil {
+			return err
+		}
+
+		if i != len(stages)-1 {
+			r, err = qtffilst.ParseReadWriter(dest)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reset truncates the file to reuse it as the dest file.
+func reset(f *os.File) error {
+	err := f.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+// loadUserDataChanges returns the classic user data text atom changes,
+// including the mirrored ItemList changes if `--mirror-udta` is set.
+func loadUserDataChanges(r qtffilst.Reader, cliOption clioption.CLIOption, itemList ilst.ItemList, deleteIds []string) (udta.UserData, []string, error) {
+	if !cliOption.MirrorUserData {
+		return cliOption.UserData, cliOption.DeleteUserDataIds, nil
+	}
+	current, err := r.ReadUserData()
+	if err != nil {
+		return nil, nil, err
+	}
+	userData, deleteUserDataIds := qtffilst.MirrorUserData(itemList, deleteIds, current)
+
+	// `--udta` and `--udta-rm` take priority over mirrored changes
+	maps.Copy(userData, cliOption.UserData)
+	for _, id := range cliOption.DeleteUserDataIds {
+		delete(userData, id)
+	}
+	return userData, append(deleteUserDataIds, cliOption.DeleteUserDataIds...), nil
+}
+
+// id32FromItemList returns the ID3v2 tag regenerated from the ItemList of r.
+// Language of the current `ID32` box is kept.
+func id32FromItemList(r qtffilst.Reader) (*id3.ID32, error) {
+	itemList, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	language := udta.LanguageUndetermined
+	current, err := r.ReadID32()
+	if /* current tag of unsupported version is replaced */ err != nil && !errors.Is(err, id3.ErrUnsupportedVersion) {
+		slog.Warn("failed to read ID3v2 tag, language of the tag is not kept", slog.String("error", err.Error()))
+	}
+	if err == nil && current != nil {
+		language = current.Language
+	}
+	return &id3.ID32{Language: language, Tag: id3.FromItemList(itemList)}, nil
+}
+
+func loadCopyItems(r qtffilst.ReadWriter, src qtffilst.Reader, cliOption clioption.CLIOption) (ilst.ItemList, []string, error) {
+	srcItemList, err := src.Read()
+	if err != nil {
+		return ilst.ItemList{}, nil, err
+	}
+	destItemList, err := r.Read()
+	if err != nil {
+		return ilst.ItemList{}, nil, err
+	}
+
+	itemList, deleteIds, err := qtffilst.CopyItems(srcItemList, destItemList, cliOption.CopyOption)
+	if err != nil {
+		return ilst.ItemList{}, nil, fmt.Errorf("CLI option `--copy-from` %w", err)
+	}
+
+	// `--data` and `--rm` take priority over copied items
+	for value, err := range ilst.EncodedValues(cliOption.ItemList) {
+		if err != nil {
+			return ilst.ItemList{}, nil, err
+		}
+		err = itemList.SetDecoded(value.Id, value.Bytes)
+		if err != nil {
+			return ilst.ItemList{}, nil, err
+		}
 	}
+	for id, v := range ilst.IterateFieldWriters(&itemList) {
+		if slices.Contains(cliOption.DeleteItemIds, id) {
+			v.Remove()
+		}
+	}
+	return itemList, append(deleteIds, cliOption.DeleteItemIds...), nil
+}
 
+// loadCopyRawItems loads the raw items (not supported by ilst.ItemList) to copy from `--copy-from` file.
+func loadCopyRawItems(r qtffilst.ReadWriter, src qtffilst.Reader, cliOption clioption.CLIOption) ([]qtffilst.RawItem, []string, error) {
+	srcRawItems, err := src.ReadRawItems()
+	if err != nil {
+		return nil, nil, err
+	}
+	destRawItems, err := r.ReadRawItems()
+	if err != nil {
+		return nil, nil, err
+	}
+	rawItems, deleteRawIds, err := qtffilst.CopyRawItems(srcRawItems, destRawItems, cliOption.CopyOption)
+	if err != nil {
+		return nil, nil, fmt.Errorf("CLI option `--copy-from` %w", err)
+	}
+	return rawItems, deleteRawIds, nil
+}
+
+func printRawItemChanges(items []qtffilst.RawItem, deleteIds []string) {
+	for _, item := range items {
+		fmt.Printf("~ %s (raw): %dB\n", item.Id, len(item.Data))
+	}
+	for _, id := range deleteIds {
+		fmt.Printf("- %s (raw)\n", id)
+	}
+}
+
+func printPlan(plan qtffilst.Plan) {
+	marks := map[qtffilst.ChangeKind]string{
+		qtffilst.ChangeKindModify: "~",
+		qtffilst.ChangeKindRemove: "-",
+		qtffilst.ChangeKindAppend: "+",
+	}
+	for _, change := range plan.Changes {
+		label := fmt.Sprintf("%s %s (%s)", marks[change.Kind], change.Id, ilst.Name(change.Id))
+		switch change.Kind {
+		case qtffilst.ChangeKindModify:
+			fmt.Printf("%s: %+v -> %+v (%+dB)\n", label, reflect.ValueOf(change.Old).Elem(), reflect.ValueOf(change.New).Elem(), change.SizeDiff)
+		case qtffilst.ChangeKindRemove:
+			fmt.Printf("%s: %+v (%+dB)\n", label, reflect.ValueOf(change.Old).Elem(), change.SizeDiff)
+		case qtffilst.ChangeKindAppend:
+			fmt.Printf("%s: %+v (%+dB)\n", label, reflect.ValueOf(change.New).Elem(), change.SizeDiff)
+		}
+	}
+	fmt.Printf(".moov.udta.meta.ilst: %+dB\n", plan.IlstSizeDiff)
+	fmt.Printf("chunk offsets: patch=%v\n", plan.PatchChunkOffsets)
+}
+
+func printMetadataChanges(metadata mdta.Metadata, deleteKeys []string) {
+	for _, key := range deleteKeys {
+		fmt.Printf("- %s\n", key)
+	}
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		fmt.Printf("~ %s: %v\n", key, metadata[key])
+	}
+}
+
+func printUserDataChanges(userData udta.UserData, deleteIds []string) {
+	for _, id := range deleteIds {
+		fmt.Printf("- %s\n", id)
+	}
+	for _, id := range slices.Sorted(maps.Keys(userData)) {
+		fmt.Printf("~ %s: %+v\n", id, userData[id])
+	}
+}
+
+func printAssetChanges(assets udta.Assets, deleteIds []string) {
+	for _, id := range deleteIds {
+		fmt.Printf("- %s (%s)\n", id, udta.AssetName(id))
+	}
+	rv := reflect.ValueOf(assets)
+	for i := range rv.NumField() {
+		if !rv.Field(i).IsNil() {
+			id := rv.Type().Field(i).Tag.Get("id")
+			fmt.Printf("~ %s (%s): %+v\n", id, udta.AssetName(id), rv.Field(i).Elem())
+		}
+	}
+}
+
+func printXMPChanges(packet []byte, remove bool) {
+	switch {
+	case remove:
+		fmt.Println("- xmp")
+	case packet != nil:
+		fmt.Printf("~ xmp: %dB\n", len(packet))
+	}
+}
+
+func printID32Changes(remove, fromItemList bool) {
+	switch {
+	case remove:
+		fmt.Println("- ID32")
+	case fromItemList:
+		fmt.Println("~ ID32: regenerated from ItemList")
+	}
+}
+
+func printChapterChanges(chapters []chapter.Chapter, remove bool) {
+	if remove {
+		fmt.Println("- chpl")
+		return
+	}
+	for _, c := range chapters {
+		fmt.Printf("~ chpl: %s\n", c)
+	}
+}
+
+func printFastStartChanges(fastStart bool, padding int32) {
+	if fastStart {
+		fmt.Printf("~ moov: move ahead of mdat (padding: %dB)\n", padding)
+	}
+}
+
+// convertLoudness converts between ReplayGain track gain/peak and SoundCheck (iTunNORM).
+// Values to write take priority over current values.
+func convertLoudness(r qtffilst.Reader, itemList *ilst.ItemList, toSoundCheck bool) error {
+	current, err := r.Read()
+	if err != nil {
+		return err
+	}
+
+	if toSoundCheck {
+		gain, peak := itemList.ReplayGainTrackGain, itemList.ReplayGainTrackPeak
+		if gain == nil {
+			gain = current.ReplayGainTrackGain
+		}
+		if peak == nil {
+			peak = current.ReplayGainTrackPeak
+		}
+		if gain == nil {
+			return errors.New("CLI option `--soundcheck-from-replaygain` replaygain_track_gain does not exist")
+		}
+		if peak == nil {
+			peak = &ilst.ReplayGainPeak{Peak: 1}
+		}
+		if !gain.Valid() || !peak.Valid() {
+			return fmt.Errorf("CLI option `--soundcheck-from-replaygain` invalid ReplayGain (\"%s\", \"%s\")", gain, peak)
+		}
+		soundCheck := ilst.NewSoundCheck(*gain, *peak)
+		itemList.SoundCheck = &soundCheck
+		return nil
+	}
+
+	soundCheck := itemList.SoundCheck
+	if soundCheck == nil {
+		soundCheck = current.SoundCheck
+	}
+	if soundCheck == nil {
+		return errors.New("CLI option `--replaygain-from-soundcheck` iTunNORM does not exist")
+	}
+	if !soundCheck.Valid() {
+		return fmt.Errorf("CLI option `--replaygain-from-soundcheck` invalid iTunNORM (\"%s\")", soundCheck)
+	}
+	gain, peak := soundCheck.ReplayGain()
+	itemList.ReplayGainTrackGain, itemList.ReplayGainTrackPeak = &gain, &peak
 	return nil
 }
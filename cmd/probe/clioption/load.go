@@ -8,7 +8,10 @@ import (
 )
 
 type CLIOption struct {
-	File f
+	File            f
+	ExtractCoverDir string
+	ExtractXMPPath  string
+	PrintChapters   bool
 }
 
 type f struct {
@@ -19,6 +22,9 @@ type f struct {
 func Load() (CLIOption, error) {
 	// Options for key features
 	filePath := pflag.StringP("file", "f", "", "file path")
+	extractCoverDir := pflag.String("extract-cover", "", "write cover art images to the directory")
+	extractXMPPath := pflag.String("extract-xmp", "", "write XMP packet to the file")
+	printChapters := pflag.Bool("chapters", false, "print chapter lists (Nero chapters and QuickTime chapter track)")
 
 	// Options for developer
 	debugLogEnable := pflag.Bool("debug", false, "Enable debug logs")
@@ -34,5 +40,5 @@ func Load() (CLIOption, error) {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
 	}
 
-	return CLIOption{file}, nil
+	return CLIOption{file, *extractCoverDir, *extractXMPPath, *printChapters}, nil
 }
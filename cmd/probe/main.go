@@ -1,15 +1,24 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/tingtt/qtffilst"
+	"github.com/tingtt/qtffilst/chapter"
 	"github.com/tingtt/qtffilst/cmd/probe/clioption"
+	"github.com/tingtt/qtffilst/id3"
 	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/xmp"
 )
 
 func main() {
@@ -31,6 +40,12 @@ func run() error {
 		return err
 	}
 
+	fileType, err := r.ReadFileType()
+	if err != nil {
+		return err
+	}
+	printFileType(fileType)
+
 	tag, err := r.Read()
 	if err != nil {
 		return err
@@ -38,16 +53,258 @@ func run() error {
 
 	fmt.Println("---")
 	for f := range iterateIDs(&tag) {
-		if f.tag.Get("id") == "covr" {
-			fmt.Printf("%s: binary data (skip display)\n", f.tag.Get("id"))
-		} else {
-			v := f.value.Elem()
-			if v.IsValid() {
-				fmt.Printf("%s: %+v\n", f.tag.Get("id"), v)
+		v := f.value.Elem()
+		if v.IsValid() {
+			fmt.Printf("%s (%s): %+v\n", f.tag.Get("id"), f.tag.Get("name"), v)
+		}
+	}
+
+	printMusicBrainzLinks(tag)
+
+	metadata, err := r.ReadMetadata()
+	if err != nil {
+		return err
+	}
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		for _, value := range metadata[key] {
+			fmt.Printf("mdta %s: %s\n", key, value)
+		}
+	}
+
+	userData, err := r.ReadUserData()
+	if err != nil {
+		return err
+	}
+	for _, id := range slices.Sorted(maps.Keys(userData)) {
+		for _, text := range userData[id] {
+			fmt.Printf("udta %s (%s) [%s]: %s\n", id, ilst.Name(id), text.Language, text.Text)
+		}
+	}
+
+	assets, err := r.ReadAssets()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(assets)
+	for i := range rv.NumField() {
+		if !rv.Field(i).IsNil() {
+			f := rv.Type().Field(i)
+			fmt.Printf("3gpp %s (%s): %+v\n", f.Tag.Get("id"), f.Tag.Get("name"), rv.Field(i).Elem())
+		}
+	}
+
+	xmpPacket, err := r.ReadXMP()
+	if err != nil {
+		return err
+	}
+	if xmpPacket != nil {
+		err = printXMP(xmpPacket, cliOption.ExtractXMPPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	id32, err := r.ReadID32()
+	switch {
+	case errors.Is(err, id3.ErrUnsupportedVersion):
+		slog.Info("skip ID3v2 tag of unsupported version", slog.String("error", err.Error()))
+	case err != nil:
+		slog.Warn("failed to read ID3v2 tag", slog.String("error", err.Error()))
+	}
+	if id32 != nil {
+		printID32(*id32)
+	}
+
+	mediaInfo, err := r.ReadMediaInfo()
+	if err != nil {
+		slog.Warn("failed to read media information", slog.String("error", err.Error()))
+	} else {
+		printMediaInfo(mediaInfo)
+	}
+
+	if cliOption.PrintChapters {
+		chapters, err := r.ReadChapters()
+		if err != nil {
+			return err
+		}
+		printChapters(chapters)
+	}
+
+	if tag.GaplessInfo != nil {
+		// read with ReadAt not to move the offset of the file shared with r
+		checkGaplessInfo(*tag.GaplessInfo, io.NewSectionReader(cliOption.File.File, 0, cliOption.File.Size), cliOption.File.Size)
+	}
+
+	if cliOption.ExtractCoverDir != "" && tag.CoverArt != nil {
+		err = extractCoverArt(*tag.CoverArt, cliOption.ExtractCoverDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkGaplessInfo cross-checks iTunSMPB with the sample count of the audio track.
+func checkGaplessInfo(gaplessInfo ilst.ITunSMPB, rs io.ReadSeeker, size int64) {
+	if !gaplessInfo.Valid() {
+		slog.Warn("invalid iTunSMPB", slog.String("value", gaplessInfo.String()))
+		return
+	}
+	duration, err := qtffilst.ReadAudioTrackDuration(rs, size)
+	if err != nil {
+		slog.Warn("failed to check iTunSMPB with the audio track", slog.String("error", err.Error()))
+		return
+	}
+	result := "ok"
+	if gaplessInfo.TotalSampleCount() != duration.SampleDuration {
+		result = "mismatch"
+	}
+	fmt.Printf("gapless_info: encoder delay + samples + padding = %d, stts = %d, mdhd = %d (timescale: %d): %s\n",
+		gaplessInfo.TotalSampleCount(), duration.SampleDuration, duration.Duration, duration.Timescale, result,
+	)
+}
+
+// printMusicBrainzLinks prints the MusicBrainz pages of the identifiers.
+func printMusicBrainzLinks(tag ilst.ItemList) {
+	const baseURL = "https://musicbrainz.org"
+	printLink := func(entity string, ids ...ilst.MusicBrainzID) {
+		for _, id := range ids {
+			if !id.Valid() {
+				slog.Warn("invalid MusicBrainz identifier", slog.String("entity", entity), slog.String("value", id.String()))
+				continue
 			}
+			fmt.Printf("musicbrainz: %s/%s/%s\n", baseURL, entity, id)
+		}
+	}
+	if tag.MusicBrainzRecordingID != nil {
+		printLink("recording", *tag.MusicBrainzRecordingID)
+	}
+	if tag.MusicBrainzTrackID != nil {
+		printLink("track", *tag.MusicBrainzTrackID)
+	}
+	if tag.MusicBrainzAlbumID != nil {
+		printLink("release", *tag.MusicBrainzAlbumID)
+	}
+	if tag.MusicBrainzReleaseGroupID != nil {
+		printLink("release-group", *tag.MusicBrainzReleaseGroupID)
+	}
+	if tag.MusicBrainzArtistID != nil {
+		printLink("artist", tag.MusicBrainzArtistID.IDs...)
+	}
+	if tag.MusicBrainzAlbumArtistID != nil {
+		printLink("artist", tag.MusicBrainzAlbumArtistID.IDs...)
+	}
+	if tag.MusicBrainzWorkID != nil {
+		printLink("work", tag.MusicBrainzWorkID.IDs...)
+	}
+}
+
+// printXMP prints Dublin Core elements of XMP packet, and writes the packet to the file if path is not empty.
+func printXMP(packet []byte, path string) error {
+	fmt.Printf("xmp: %dB\n", len(packet))
+	dc, err := xmp.ParseDublinCore(packet)
+	if err != nil {
+		slog.Warn("failed to parse XMP packet", slog.String("error", err.Error()))
+	}
+	rv := reflect.ValueOf(dc)
+	for i := range rv.NumField() {
+		if rv.Field(i).Len() != 0 {
+			fmt.Printf("xmp dc:%s: %q\n", strings.ToLower(rv.Type().Field(i).Name), rv.Field(i).Interface())
+		}
+	}
+
+	if path == "" {
+		return nil
+	}
+	err = os.WriteFile(path, packet, 0644)
+	if err != nil {
+		return err
+	}
+	slog.Info("extract XMP packet", slog.String("path", path))
+	return nil
+}
+
+// printID32 prints the ItemList view of the ID3v2 tag.
+func printID32(id32 id3.ID32) {
+	fmt.Printf("id32 [%s]: ID3v2.%d, %d frames\n", id32.Language, id32.Tag.Version, len(id32.Tag.Frames))
+	itemList := id32.Tag.ItemList()
+	for f := range iterateIDs(&itemList) {
+		v := f.value.Elem()
+		if v.IsValid() {
+			fmt.Printf("id32 %s (%s): %+v\n", f.tag.Get("id"), f.tag.Get("name"), v)
+		}
+	}
+}
+
+// printFileType prints the brands of `ftyp` box.
+func printFileType(fileType qtffilst.FileType) {
+	if fileType.MajorBrand == "" {
+		fmt.Println("ftyp: none (QuickTime movie)")
+		return
+	}
+	fmt.Printf("ftyp: %q (minor version: %d, compatible: %q, extension: %s)\n",
+		fileType.MajorBrand, fileType.MinorVersion, fileType.CompatibleBrands, fileType.Extension(),
+	)
+}
+
+// printMediaInfo prints the duration of the movie and the codec, format and bitrate of each track.
+func printMediaInfo(mediaInfo qtffilst.MediaInfo) {
+	fmt.Printf("duration: %s\n", mediaInfo.DurationTime())
+	for _, track := range mediaInfo.Tracks {
+		fields := []string{}
+		if track.Format != "" {
+			fields = append(fields, track.Format)
+		}
+		if track.Codec != "" {
+			fields = append(fields, fmt.Sprintf("(%s)", track.Codec))
+		}
+		if track.SampleRate != 0 {
+			fields = append(fields, fmt.Sprintf("%dHz", track.SampleRate))
+		}
+		if track.Channels != 0 {
+			fields = append(fields, fmt.Sprintf("%dch", track.Channels))
 		}
+		if track.BitDepth != 0 {
+			fields = append(fields, fmt.Sprintf("%dbit", track.BitDepth))
+		}
+		if track.Width != 0 || track.Height != 0 {
+			fields = append(fields, fmt.Sprintf("%dx%d", track.Width, track.Height))
+		}
+		fields = append(fields, fmt.Sprintf("%dkbps", (track.Bitrate()+500)/1000), track.DurationTime().String())
+		fmt.Printf("track %d (%s): %s\n", track.Id, track.HandlerType, strings.Join(fields, " "))
 	}
+}
 
+// printChapters prints the chapter lists in the format of `qtffilst --chapters`.
+func printChapters(chapters chapter.Chapters) {
+	for _, list := range []struct {
+		source   string
+		chapters []chapter.Chapter
+	}{{".moov.udta.chpl", chapters.Nero}, {"chapter track", chapters.Track}} {
+		if len(list.chapters) == 0 {
+			continue
+		}
+		fmt.Printf("--- chapters (%s)\n", list.source)
+		for _, c := range list.chapters {
+			fmt.Println(c)
+		}
+	}
+}
+
+func extractCoverArt(coverArt ilst.CoverArt, dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for i, image := range coverArt.Images {
+		imagePath := filepath.Join(dir, fmt.Sprintf("cover%d%s", i+1, image.Format.Extension()))
+		err = os.WriteFile(imagePath, image.Data, 0644)
+		if err != nil {
+			return err
+		}
+		slog.Info("extract cover art", slog.String("path", imagePath))
+	}
 	return nil
 }
 
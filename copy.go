@@ -0,0 +1,181 @@
+package qtffilst
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tingtt/qtffilst/ilst"
+)
+
+type CopyOption struct {
+	// Copy only the items of these ids. All items are copied if empty.
+	// Accepts box ids, friendly names and field names of ilst.ItemList,
+	// and the ids of raw items (e.g. "----:com.example:name").
+	IncludeIds []string
+	// Do not copy the items of these ids.
+	// Accepts the same ids as IncludeIds.
+	ExcludeIds []string
+	// Remove the items of dest that do not exist in src.
+	// Excluded items are kept as is.
+	Replace bool
+}
+
+// Copy copies the ItemList and the raw items of src to the file of rw, and writes the result to dest.
+// `.moov.udta.meta.ilst` is created in the file of rw if it does not exist.
+func Copy(src Reader, rw ReadWriter, dest, tmpDest, tmpDest2 *os.File, option CopyOption) error {
+	srcItemList, err := src.Read()
+	if err != nil {
+		return err
+	}
+	destItemList, err := rw.Read()
+	if err != nil {
+		return err
+	}
+	itemList, deleteIds, err := CopyItems(srcItemList, destItemList, option)
+	if err != nil {
+		return err
+	}
+
+	srcRawItems, err := src.ReadRawItems()
+	if err != nil {
+		return err
+	}
+	destRawItems, err := rw.ReadRawItems()
+	if err != nil {
+		return err
+	}
+	rawItems, deleteRawIds, err := CopyRawItems(srcRawItems, destRawItems, option)
+	if err != nil {
+		return err
+	}
+	if len(rawItems) == 0 && len(deleteRawIds) == 0 {
+		return rw.Write(dest, tmpDest, tmpDest2, itemList, deleteIds)
+	}
+
+	// write ItemList to the intermediate file, and then raw items to dest
+	itemListDest, err := os.CreateTemp("", "qtffilst-copy-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(itemListDest.Name())
+	defer itemListDest.Close()
+	err = rw.Write(itemListDest, tmpDest, tmpDest2, itemList, deleteIds)
+	if err != nil {
+		return err
+	}
+	next, err := ParseReadWriter(itemListDest)
+	if err != nil {
+		return err
+	}
+	err = tmpDest.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = tmpDest.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	return next.WriteRawItems(dest, tmpDest, rawItems, deleteRawIds)
+}
+
+// CopyItems returns the items to write and the ids to delete
+// for copying the ItemList of src to dest.
+func CopyItems(src, dest ilst.ItemList, option CopyOption) (itemList ilst.ItemList, deleteIds []string, err error) {
+	includeIds, err := resolveIds(option.IncludeIds)
+	if err != nil {
+		return ilst.ItemList{}, nil, fmt.Errorf("include %w", err)
+	}
+	excludeIds, err := resolveIds(option.ExcludeIds)
+	if err != nil {
+		return ilst.ItemList{}, nil, fmt.Errorf("exclude %w", err)
+	}
+	matchCopyTarget := func(id string) bool {
+		if len(includeIds) != 0 && !slices.Contains(includeIds, id) {
+			return false
+		}
+		return !slices.Contains(excludeIds, id)
+	}
+
+	itemList = src
+	for id, v := range ilst.IterateFieldWriters(&itemList) {
+		if !matchCopyTarget(id) {
+			v.Remove()
+		}
+	}
+
+	if option.Replace {
+		copyItems := maps.Collect(ilst.Values(&itemList))
+		for id := range ilst.Values(&dest) {
+			if _, exists := copyItems[id]; exists || !matchCopyTarget(id) {
+				continue
+			}
+			deleteIds = append(deleteIds, id)
+		}
+	}
+	return itemList, deleteIds, nil
+}
+
+// CopyRawItems returns the raw items to write and the ids to delete
+// for copying the raw items of src to dest, with the same option as CopyItems.
+func CopyRawItems(src, dest []RawItem, option CopyOption) (items []RawItem, deleteIds []string, err error) {
+	includeIds, err := resolveIds(option.IncludeIds)
+	if err != nil {
+		return nil, nil, fmt.Errorf("include %w", err)
+	}
+	excludeIds, err := resolveIds(option.ExcludeIds)
+	if err != nil {
+		return nil, nil, fmt.Errorf("exclude %w", err)
+	}
+	matchCopyTarget := func(id string) bool {
+		if len(includeIds) != 0 && !slices.Contains(includeIds, id) {
+			return false
+		}
+		return !slices.Contains(excludeIds, id)
+	}
+
+	items = []RawItem{}
+	for _, item := range src {
+		if matchCopyTarget(item.Id) {
+			items = append(items, item)
+		}
+	}
+
+	if option.Replace {
+		for _, item := range dest {
+			copied := slices.ContainsFunc(items, func(v RawItem) bool { return v.Id == item.Id })
+			if copied || !matchCopyTarget(item.Id) || slices.Contains(deleteIds, item.Id) {
+				continue
+			}
+			deleteIds = append(deleteIds, item.Id)
+		}
+	}
+	return items, deleteIds, nil
+}
+
+// resolveIds resolves the ids of ilst.ItemList, and accepts the ids of raw items as is.
+func resolveIds(names []string) ([]string, error) {
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, ok := ilst.ResolveId(name)
+		if !ok && rawItemIdLike(name) {
+			id, ok = name, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid ItemList id or name (\"%s\")", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// rawItemIdLike reports whether the id can be the id of raw item (box name or freeform id).
+func rawItemIdLike(id string) bool {
+	if _, _, freeform := ilst.ParseFreeformId(id); freeform {
+		return true
+	}
+	return len(id) == 4 || strings.HasPrefix(id, "(c)") && len(id) == 7
+}
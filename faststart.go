@@ -0,0 +1,72 @@
+package qtffilst
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+var (
+	ErrMoovBoxDoesNotExist = errors.New(".moov does not exists")
+)
+
+// WriteFastStart writes the file with `moov` moved ahead of the first `mdat` (or `moof`) for progressive playback,
+// and the chunk offsets (`stco`, `co64`) patched.
+// `free` boxes directly after `moov` move with it, or are replaced by a `free` box with padding bytes of data
+// if padding is not 0, which absorbs the size change of `moov` on the later tag edits (see copyWithOffsetsPatch).
+func (r *readWriter) WriteFastStart(dest, tmpDest *os.File, padding int32) error {
+	boxes := []Box{}
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return err
+		}
+		if box.Level == ROOT_LEVEL && !box.IsContainable {
+			boxes = append(boxes, box)
+		}
+	}
+
+	moovIndex := slices.IndexFunc(boxes, func(box Box) bool { return box.Name == "moov" })
+	if moovIndex == -1 {
+		return ErrMoovBoxDoesNotExist
+	}
+	moovEnd := moovIndex + 1
+	for moovEnd < len(boxes) && boxes[moovEnd].Name == "free" {
+		moovEnd++
+	}
+	moov := slices.Clone(boxes[moovIndex:moovEnd])
+	if padding > 0 {
+		for _, free := range moov[1:] {
+			slog.Info("remove", slog.String("id", "free"), slog.String("diff", fmt.Sprintf("%+d", -free.DataSize-8)))
+		}
+		moov = moov[:1]
+	}
+
+	mediaDataIndex := slices.IndexFunc(boxes, func(box Box) bool { return box.Name == "mdat" || box.Name == "moof" })
+	if mediaDataIndex != -1 && mediaDataIndex < moovIndex {
+		boxes = slices.Delete(boxes, moovIndex, moovEnd)
+		boxes = slices.Insert(boxes, mediaDataIndex, moov...)
+		moovIndex = mediaDataIndex
+		slog.Info("move", slog.String("id", "moov"), slog.Int("index", mediaDataIndex))
+	} else {
+		boxes = slices.Replace(boxes, moovIndex, moovEnd, moov...)
+	}
+
+	for i, box := range boxes {
+		err := copy(r.f, box.DataPosition-8 /* size, name */, box.DataSize+8, tmpDest)
+		if err != nil {
+			return err
+		}
+		if i == moovIndex && padding > 0 {
+			err = writeBox(tmpDest, "free", make([]byte, padding))
+			if err != nil {
+				return err
+			}
+			slog.Info("append", slog.String("id", "free"), slog.String("diff", fmt.Sprintf("%+d", padding+8)))
+		}
+	}
+
+	// `free` box after `moov` is written as given, not resized to absorb the size change of `moov`
+	return r.copyWithMovedOffsetsPatch(tmpDest, dest)
+}
@@ -0,0 +1,117 @@
+package qtffilst
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func testBox(name string, data ...[]byte) []byte {
+	buf := &bytes.Buffer{}
+	writeBox(buf, name, slices.Concat(data...))
+	return buf.Bytes()
+}
+
+// testMoov returns `moov` with the chunk offsets.
+func testMoov(offsets ...int64) []byte {
+	stco := slices.Concat(u32(0) /* version, flags */, u32(int64(len(offsets))))
+	for _, offset := range offsets {
+		stco = append(stco, u32(offset)...)
+	}
+	return testBox("moov", testBox("trak", testBox("mdia", testBox("minf", testBox("stbl", testBox("stco", stco))))))
+}
+
+func TestWriteFastStart(t *testing.T) {
+	ftyp := testBox("ftyp", []byte("M4A "), u32(0), []byte("M4A isom"))
+	mdat := testBox("mdat", []byte("chunk1chunk2"))
+	free := testBox("free", make([]byte, 8))
+	mdatDataPosition := int64(len(ftyp) + 8)
+	moovSize := int64(len(testMoov(0, 0)))
+
+	tests := []struct {
+		name    string
+		src     []byte
+		padding int32
+		want    []byte
+	}{
+		{
+			name:    "moov is moved ahead of mdat",
+			src:     slices.Concat(ftyp, mdat, testMoov(mdatDataPosition, mdatDataPosition+6)),
+			padding: 0,
+			want:    slices.Concat(ftyp, testMoov(mdatDataPosition+moovSize, mdatDataPosition+moovSize+6), mdat),
+		},
+		{
+			name:    "free after moov moves with it",
+			src:     slices.Concat(ftyp, mdat, testMoov(mdatDataPosition, mdatDataPosition+6), free),
+			padding: 0,
+			want:    slices.Concat(ftyp, testMoov(mdatDataPosition+moovSize+16, mdatDataPosition+moovSize+22), free, mdat),
+		},
+		{
+			name:    "free after moov is replaced by padding",
+			src:     slices.Concat(ftyp, mdat, testMoov(mdatDataPosition, mdatDataPosition+6), free),
+			padding: 32,
+			want:    slices.Concat(ftyp, testMoov(mdatDataPosition+moovSize+40, mdatDataPosition+moovSize+46), testBox("free", make([]byte, 32)), mdat),
+		},
+		{
+			name:    "moov is already ahead of mdat",
+			src:     slices.Concat(ftyp, testMoov(mdatDataPosition+moovSize, mdatDataPosition+moovSize+6), mdat),
+			padding: 0,
+			want:    slices.Concat(ftyp, testMoov(mdatDataPosition+moovSize, mdatDataPosition+moovSize+6), mdat),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "src"), tt.src, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			r, err := open(filepath.Join(dir, "src"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.f.Close()
+			dest, err := os.Create(filepath.Join(dir, "dest"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dest.Close()
+			tmpDest, err := os.Create(filepath.Join(dir, "tmp"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tmpDest.Close()
+
+			err = r.WriteFastStart(dest, tmpDest, tt.padding)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(dir, "dest"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("dest = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFastStartWithoutMoov(t *testing.T) {
+	dir := t.TempDir()
+	src := slices.Concat(testBox("ftyp", []byte("M4A "), u32(0)), testBox("mdat", []byte("chunk1")))
+	if err := os.WriteFile(filepath.Join(dir, "src"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := open(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.f.Close()
+
+	if err := r.WriteFastStart(nil, nil, 0); !errors.Is(err, ErrMoovBoxDoesNotExist) {
+		t.Errorf("error = %v, want %v", err, ErrMoovBoxDoesNotExist)
+	}
+}
@@ -0,0 +1,112 @@
+package qtffilst
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+const BrandQuickTime = "qt  "
+
+// FileType is the content of `ftyp` box.
+// https://developer.apple.com/documentation/quicktime-file-format/file_type_compatibility_atom
+type FileType struct {
+	MajorBrand       string
+	MinorVersion     int32
+	CompatibleBrands []string
+}
+
+// QuickTime reports whether the file is QuickTime movie file (major brand "qt  ").
+// Files without `ftyp` box are also QuickTime movie files (created before `ftyp` was introduced).
+func (f FileType) QuickTime() bool {
+	return f.MajorBrand == "" || f.MajorBrand == BrandQuickTime
+}
+
+// Compatible reports whether the brand is the major brand or one of the compatible brands.
+func (f FileType) Compatible(brand string) bool {
+	return f.MajorBrand == brand || slices.Contains(f.CompatibleBrands, brand)
+}
+
+// Extension returns the conventional file extension of the major brand (e.g. ".m4a").
+func (f FileType) Extension() string {
+	switch {
+	case f.QuickTime():
+		return ".mov"
+	case f.MajorBrand == "M4A ":
+		return ".m4a"
+	case f.MajorBrand == "M4B ":
+		return ".m4b"
+	case f.MajorBrand == "M4P ":
+		return ".m4p"
+	case strings.HasPrefix(f.MajorBrand, "M4V"):
+		return ".m4v"
+	case strings.HasPrefix(f.MajorBrand, "3g2"):
+		return ".3g2"
+	case strings.HasPrefix(f.MajorBrand, "3g"):
+		return ".3gp"
+	default:
+		return ".mp4"
+	}
+}
+
+// ReadFileType reads `ftyp` box.
+// Returns zero FileType if `ftyp` box does not exist.
+func (r *reader) ReadFileType() (FileType, error) {
+	return readFileType(r.f, r.size)
+}
+
+// readFileType reads `ftyp` box before `moov` and `mdat` boxes.
+func readFileType(rs io.ReadSeeker, size int64) (FileType, error) {
+	for offset := int64(0); offset+8 <= size; {
+		_, err := rs.Seek(offset, io.SeekStart)
+		if err != nil {
+			return FileType{}, err
+		}
+		boxSize, boxName, err := readBoxHeader(rs)
+		if err != nil {
+			return FileType{}, err
+		}
+		if boxSize < 8 || offset+int64(boxSize) > size {
+			return FileType{}, fmt.Errorf("invalid box size (%s: %d)", boxName, boxSize)
+		}
+		switch boxName {
+		case "ftyp":
+			buf := &bytes.Buffer{}
+			err = copy(rs, offset+8, boxSize-8, buf)
+			if err != nil {
+				return FileType{}, err
+			}
+			return decodeFileType(buf.Bytes())
+		case "moov", "mdat":
+			return FileType{}, nil
+		}
+		offset += int64(boxSize)
+	}
+	return FileType{}, nil
+}
+
+func decodeFileType(data []byte) (FileType, error) {
+	if len(data) < 8 {
+		return FileType{}, io.ErrUnexpectedEOF
+	}
+	minorVersion, err := binary.BigEdian.ReadI32(bytes.NewReader(data[4:8]))
+	if err != nil {
+		return FileType{}, err
+	}
+	fileType := FileType{
+		MajorBrand:       string(data[:4]),
+		MinorVersion:     minorVersion,
+		CompatibleBrands: []string{},
+	}
+	for offset := 8; offset+4 <= len(data); offset += 4 {
+		if /* padding */ bytes.Equal(data[offset:offset+4], []byte{0x0, 0x0, 0x0, 0x0}) {
+			continue
+		}
+		fileType.CompatibleBrands = append(fileType.CompatibleBrands, string(data[offset:offset+4]))
+	}
+	return fileType, nil
+}
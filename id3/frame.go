@@ -0,0 +1,198 @@
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+// Text encodings of the frames.
+const (
+	EncodingISO88591 byte = 0
+	EncodingUTF16    byte = 1 // with BOM
+	EncodingUTF16BE  byte = 2
+	EncodingUTF8     byte = 3
+)
+
+// Text decodes the text information frame (e.g. TIT2).
+// Values are separated by null in ID3v2.4.
+func (f Frame) Text() ([]string, error) {
+	if len(f.Data) < 1 {
+		return nil, ErrInvalidLength
+	}
+	encoding, data := f.Data[0], f.Data[1:]
+	values := []string{}
+	for len(data) != 0 {
+		value, rest, err := readString(encoding, data)
+		if err != nil {
+			return nil, err
+		}
+		values, data = append(values, value), rest
+	}
+	return values, nil
+}
+
+// NewTextFrame creates the text information frame encoded in UTF-8 (ID3v2.4).
+func NewTextFrame(id string, values ...string) Frame {
+	buf := &bytes.Buffer{}
+	buf.WriteByte(EncodingUTF8)
+	buf.WriteString(strings.Join(values, "\x00"))
+	return Frame{id, buf.Bytes()}
+}
+
+// Comment is the content of the comment frame (COMM) or the unsynchronised lyrics frame (USLT).
+type Comment struct {
+	// ISO 639-2 code (e.g. "eng")
+	Language    string
+	Description string
+	Text        string
+}
+
+func (f Frame) Comment() (Comment, error) {
+	if len(f.Data) < 4 {
+		return Comment{}, ErrInvalidLength
+	}
+	encoding, language := f.Data[0], string(f.Data[1:4])
+	description, data, err := readString(encoding, f.Data[4:])
+	if err != nil {
+		return Comment{}, err
+	}
+	text, _, err := readString(encoding, data)
+	if err != nil {
+		return Comment{}, err
+	}
+	return Comment{language, description, text}, nil
+}
+
+// NewCommentFrame creates the comment frame (COMM) or the unsynchronised lyrics frame (USLT) encoded in UTF-8 (ID3v2.4).
+func NewCommentFrame(id string, comment Comment) Frame {
+	language := comment.Language
+	if len(language) != 3 {
+		language = "und"
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteByte(EncodingUTF8)
+	buf.WriteString(language)
+	buf.WriteString(comment.Description)
+	buf.WriteByte(0x0)
+	buf.WriteString(comment.Text)
+	return Frame{id, buf.Bytes()}
+}
+
+// PictureType is the type of the attached picture (e.g. front cover).
+type PictureType byte
+
+const (
+	PictureTypeOther      PictureType = 0x00
+	PictureTypeFrontCover PictureType = 0x03
+	PictureTypeBackCover  PictureType = 0x04
+)
+
+// Picture is the content of the attached picture frame (APIC).
+type Picture struct {
+	MIMEType    string
+	Type        PictureType
+	Description string
+	Data        []byte
+}
+
+func (p Picture) String() string {
+	return fmt.Sprintf("{MIMEType:%s Type:%d Description:%s Data:(%dB)}", p.MIMEType, p.Type, p.Description, len(p.Data))
+}
+
+func (f Frame) Picture() (Picture, error) {
+	if len(f.Data) < 1 {
+		return Picture{}, ErrInvalidLength
+	}
+	encoding := f.Data[0]
+	mimeType, data, err := readString(EncodingISO88591, f.Data[1:])
+	if err != nil {
+		return Picture{}, err
+	}
+	if len(data) < 1 {
+		return Picture{}, ErrInvalidLength
+	}
+	pictureType := PictureType(data[0])
+	description, data, err := readString(encoding, data[1:])
+	if err != nil {
+		return Picture{}, err
+	}
+	return Picture{mimeType, pictureType, description, data}, nil
+}
+
+// NewPictureFrame creates the attached picture frame (APIC) encoded in UTF-8 (ID3v2.4).
+func NewPictureFrame(picture Picture) Frame {
+	buf := &bytes.Buffer{}
+	buf.WriteByte(EncodingUTF8)
+	buf.WriteString(picture.MIMEType)
+	buf.WriteByte(0x0)
+	buf.WriteByte(byte(picture.Type))
+	buf.WriteString(picture.Description)
+	buf.WriteByte(0x0)
+	buf.Write(picture.Data)
+	return Frame{"APIC", buf.Bytes()}
+}
+
+// readString reads the string terminated by null (or the end of data) of the encoding,
+// and returns the rest of data.
+func readString(encoding byte, data []byte) (str string, rest []byte, err error) {
+	switch encoding {
+	case EncodingISO88591, EncodingUTF8:
+		end := bytes.IndexByte(data, 0x0)
+		if end == -1 {
+			end, rest = len(data), []byte{}
+		} else {
+			rest = data[end+1:]
+		}
+		if encoding == EncodingUTF8 {
+			return string(data[:end]), rest, nil
+		}
+		runes := make([]rune, 0, end)
+		for _, b := range data[:end] {
+			runes = append(runes, rune(b))
+		}
+		return string(runes), rest, nil
+	case EncodingUTF16, EncodingUTF16BE:
+		end := len(data) &^ 1
+		rest = []byte{}
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0x0 && data[i+1] == 0x0 {
+				end, rest = i, data[i+2:]
+				break
+			}
+		}
+		return decodeUTF16(encoding, data[:end]), rest, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported text encoding (%d)", encoding)
+	}
+}
+
+// decodeUTF16 decodes UTF-16 text. Byte order of EncodingUTF16 follows BOM (big-endian if BOM does not exist).
+func decodeUTF16(encoding byte, data []byte) string {
+	littleEndian := false
+	if encoding == EncodingUTF16 && len(data) >= 2 {
+		switch {
+		case data[0] == 0xFF && data[1] == 0xFE:
+			littleEndian, data = true, data[2:]
+		case data[0] == 0xFE && data[1] == 0xFF:
+			data = data[2:]
+		}
+	}
+	r := bytes.NewReader(data)
+	units := make([]uint16, 0, len(data)/2)
+	for r.Len() >= 2 {
+		unit, err := binary.BigEdian.ReadI16(r)
+		if err != nil {
+			break
+		}
+		if littleEndian {
+			unit = int16(bits.ReverseBytes16(uint16(unit)))
+		}
+		units = append(units, uint16(unit))
+	}
+	return string(utf16.Decode(units))
+}
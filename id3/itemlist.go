@@ -0,0 +1,152 @@
+package id3
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tingtt/qtffilst/ilst"
+)
+
+// textItemIds maps the text information frames to the ids of ilst.ItemList.
+// Values are converted in the same way as the text of CLI (e.g. "3/12" for `trkn`).
+var textItemIds = []struct{ frameId, itemId string }{
+	{"TIT2", "(c)nam"},
+	{"TPE1", "(c)ART"},
+	{"TALB", "(c)alb"},
+	{"TPE2", "aART"},
+	{"TCOM", "(c)wrt"},
+	{"TCON", "(c)gen"},
+	{"TIT1", "(c)grp"},
+	{"TIT3", "(c)st3"},
+	{"TDRC", "(c)day"},
+	{"TYER", "(c)day"}, // ID3v2.3
+	{"TRCK", "trkn"},
+	{"TPOS", "disk"},
+	{"TBPM", "tmpo"},
+	{"TCOP", "cprt"},
+	{"TPUB", "(c)pub"},
+	{"TENC", "(c)enc"},
+	{"TSSE", "(c)too"},
+	{"TSRC", "xid "},
+	{"TSOT", "sonm"},
+	{"TSOP", "soar"},
+	{"TSOA", "soal"},
+	{"TSO2", "soaa"},
+	{"TSOC", "soco"},
+}
+
+// ItemList returns the view of the tag comparable to ilst.ItemList.
+// Frames that ilst.ItemList does not support and invalid values are skipped.
+func (t Tag) ItemList() ilst.ItemList {
+	itemList := ilst.ItemList{}
+	set := func(itemId, value string) {
+		for id, v := range ilst.IterateFieldWriters(&itemList) {
+			if id != itemId {
+				continue
+			}
+			data, err := v.GetDecorder().Decode(value)
+			if err == nil {
+				_ = itemList.SetDecoded(id, data)
+			}
+			return
+		}
+	}
+
+	for _, m := range textItemIds {
+		for _, frame := range t.FramesOf(m.frameId) {
+			values, err := frame.Text()
+			if err != nil || len(values) == 0 {
+				continue
+			}
+			set(m.itemId, strings.Join(values, "; "))
+			break
+		}
+	}
+	for _, m := range []struct{ frameId, itemId string }{{"COMM", "(c)cmt"}, {"USLT", "(c)lyr"}} {
+		for _, frame := range t.FramesOf(m.frameId) {
+			comment, err := frame.Comment()
+			if err != nil || /* e.g. iTunNORM */ comment.Description != "" {
+				continue
+			}
+			set(m.itemId, comment.Text)
+			break
+		}
+	}
+	for _, frame := range t.FramesOf("APIC") {
+		picture, err := frame.Picture()
+		if err != nil {
+			continue
+		}
+		image, err := ilst.NewImage(picture.Data)
+		if err != nil {
+			continue
+		}
+		if itemList.CoverArt == nil {
+			itemList.CoverArt = &ilst.CoverArt{}
+		}
+		itemList.CoverArt.Images = append(itemList.CoverArt.Images, image)
+	}
+	return itemList
+}
+
+// FromItemList creates ID3v2.4 tag from the items of ilst.ItemList that have the corresponding frame.
+// The first image of cover art is the front cover.
+func FromItemList(itemList ilst.ItemList) Tag {
+	tag := Tag{Version: 4, Frames: []Frame{}}
+	values := map[string]any{}
+	for id, value := range ilst.Values(&itemList) {
+		values[id] = value
+	}
+
+	for _, m := range textItemIds {
+		if m.frameId == "TYER" {
+			continue
+		}
+		switch value := values[m.itemId].(type) {
+		case nil:
+		case *ilst.TrackNumber:
+			tag.Frames = append(tag.Frames, NewTextFrame(m.frameId, slashed(value.Number, value.Total)))
+		case *ilst.DiskNumber:
+			tag.Frames = append(tag.Frames, NewTextFrame(m.frameId, slashed(value.Number, value.Total)))
+		case *ilst.Int16WithHeader0x15_0:
+			tag.Frames = append(tag.Frames, NewTextFrame(m.frameId, fmt.Sprint(value.Value)))
+		default:
+			if text, ok := ilst.Text(value); ok {
+				tag.Frames = append(tag.Frames, NewTextFrame(m.frameId, text))
+			}
+		}
+	}
+	if text, ok := ilst.Text(values["(c)cmt"]); ok {
+		tag.Frames = append(tag.Frames, NewCommentFrame("COMM", Comment{Text: text}))
+	}
+	if text, ok := ilst.Text(values["(c)lyr"]); ok {
+		tag.Frames = append(tag.Frames, NewCommentFrame("USLT", Comment{Text: text}))
+	}
+	if itemList.CoverArt != nil {
+		for i, image := range itemList.CoverArt.Images {
+			pictureType := PictureTypeOther
+			if i == 0 {
+				pictureType = PictureTypeFrontCover
+			}
+			tag.Frames = append(tag.Frames, NewPictureFrame(Picture{
+				MIMEType: mimeTypes[image.Format],
+				Type:     pictureType,
+				Data:     image.Data,
+			}))
+		}
+	}
+	return tag
+}
+
+var mimeTypes = map[ilst.ImageFormat]string{
+	ilst.ImageFormatJPEG: "image/jpeg",
+	ilst.ImageFormatPNG:  "image/png",
+	ilst.ImageFormatBMP:  "image/bmp",
+}
+
+func slashed(number, total int16) string {
+	if total == 0 {
+		return fmt.Sprint(number)
+	}
+	return fmt.Sprintf("%d/%d", number, total)
+}
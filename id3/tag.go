@@ -0,0 +1,252 @@
+package id3
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+	"github.com/tingtt/qtffilst/udta"
+)
+
+var (
+	ErrInvalidLength      = errors.New("invalid length")
+	ErrInvalidHeader      = errors.New("invalid ID3v2 header")
+	ErrUnsupportedVersion = errors.New("unsupported ID3v2 version")
+	ErrEncryptedFrame     = errors.New("encrypted frame")
+)
+
+// ID32 is the ID3v2 tag in `.moov.udta.meta.ID32` box.
+// https://mp4ra.org/references#id3v2
+type ID32 struct {
+	Language udta.Language
+	Tag      Tag
+}
+
+// DecodeID32 decodes the data of `ID32` box.
+// Data is "<version, flags (32 bit)><pad (1 bit)><language (15 bit)><ID3v2 tag>".
+func DecodeID32(data []byte) (ID32, error) {
+	if len(data) < 6 {
+		return ID32{}, ErrInvalidLength
+	}
+	language, err := binary.BigEdian.ReadI16(bytes.NewReader(data[4:6]))
+	if err != nil {
+		return ID32{}, err
+	}
+	tag, err := Decode(data[6:])
+	if err != nil {
+		return ID32{}, err
+	}
+	return ID32{
+		Language: udta.Language(language & 0x7FFF),
+		Tag:      tag,
+	}, nil
+}
+
+func (i ID32) Bytes() []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(bytes.Repeat([]byte{0x0}, 4) /* version, flags */)
+	buf.Write(binary.BigEdian.BytesI16(int16(i.Language & 0x7FFF)))
+	buf.Write(i.Tag.Bytes())
+	return buf.Bytes()
+}
+
+// Tag is ID3v2.3 or ID3v2.4 tag.
+// https://id3.org/id3v2.4.0-structure
+type Tag struct {
+	// Major version (3 or 4). Tag is encoded as ID3v2.4 unless 3.
+	Version byte
+	Frames  []Frame
+}
+
+// Frame is the frame of ID3v2 tag.
+// Data of the frame is resynchronised and decompressed on decoding.
+type Frame struct {
+	Id   string
+	Data []byte
+}
+
+// FramesOf returns the frames of the id.
+func (t Tag) FramesOf(id string) []Frame {
+	frames := []Frame{}
+	for _, frame := range t.Frames {
+		if frame.Id == id {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+const (
+	flagUnsynchronisation = 0x80
+	flagExtendedHeader    = 0x40
+
+	// Frame format flags of ID3v2.3
+	v3FlagCompression = 0x80
+	v3FlagEncryption  = 0x40
+	v3FlagGrouping    = 0x20
+	// Frame format flags of ID3v2.4
+	v4FlagGrouping            = 0x40
+	v4FlagCompression         = 0x08
+	v4FlagEncryption          = 0x04
+	v4FlagUnsynchronisation   = 0x02
+	v4FlagDataLengthIndicator = 0x01
+)
+
+// Decode decodes ID3v2.3 or ID3v2.4 tag.
+// Encrypted frames and the frames that cannot be decoded (e.g. invalid compressed data) are skipped,
+// and the frame exceeding the tag ends the frames.
+// ErrUnsupportedVersion is returned for other versions (e.g. ID3v2.2).
+func Decode(data []byte) (Tag, error) {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return Tag{}, ErrInvalidHeader
+	}
+	version, flags := data[3], data[5]
+	if version != 3 && version != 4 {
+		return Tag{}, fmt.Errorf("%w (2.%d)", ErrUnsupportedVersion, version)
+	}
+	size := syncsafe(data[6:10])
+	if len(data) < 10+size {
+		return Tag{}, ErrInvalidLength
+	}
+	body := data[10 : 10+size]
+	if /* ID3v2.4 has the flag per frame */ version == 3 && flags&flagUnsynchronisation != 0 {
+		body = resynchronise(body)
+	}
+	if flags&flagExtendedHeader != 0 {
+		if len(body) < 4 {
+			return Tag{}, ErrInvalidLength
+		}
+		extendedHeaderSize32, err := binary.BigEdian.ReadI32(bytes.NewReader(body[:4]))
+		if err != nil {
+			return Tag{}, err
+		}
+		extendedHeaderSize := int(uint32(extendedHeaderSize32)) + 4 /* size field is not included in ID3v2.3 */
+		if version == 4 {
+			extendedHeaderSize = syncsafe(body[:4])
+		}
+		if len(body) < extendedHeaderSize {
+			return Tag{}, ErrInvalidLength
+		}
+		body = body[extendedHeaderSize:]
+	}
+
+	tag := Tag{Version: version, Frames: []Frame{}}
+	// frames are followed by padding (0x00)
+	for offset := 0; offset+10 <= len(body) && body[offset] != 0x0; {
+		id := string(body[offset : offset+4])
+		frameSize32, err := binary.BigEdian.ReadI32(bytes.NewReader(body[offset+4 : offset+8]))
+		if err != nil {
+			return Tag{}, err
+		}
+		frameSize := int(uint32(frameSize32))
+		if version == 4 {
+			frameSize = syncsafe(body[offset+4 : offset+8])
+		}
+		if len(body) < offset+10+frameSize {
+			break
+		}
+		frameData, err := frameContent(version, body[offset+9], body[offset+10:offset+10+frameSize])
+		offset += 10 + frameSize
+		if /* encrypted, or invalid frame */ err != nil {
+			continue
+		}
+		tag.Frames = append(tag.Frames, Frame{id, frameData})
+	}
+	return tag, nil
+}
+
+// frameContent returns the content of the frame without the additional header bytes of the format flags.
+func frameContent(version, flags byte, data []byte) ([]byte, error) {
+	var (
+		headerSize int
+		compressed bool
+		encrypted  bool
+	)
+	switch version {
+	case 3:
+		compressed, encrypted = flags&v3FlagCompression != 0, flags&v3FlagEncryption != 0
+		if compressed {
+			headerSize += 4 /* decompressed size */
+		}
+		if encrypted {
+			headerSize += 1 /* encryption method */
+		}
+		if flags&v3FlagGrouping != 0 {
+			headerSize += 1 /* group identifier */
+		}
+	case 4:
+		compressed, encrypted = flags&v4FlagCompression != 0, flags&v4FlagEncryption != 0
+		if flags&v4FlagGrouping != 0 {
+			headerSize += 1 /* group identifier */
+		}
+		if encrypted {
+			headerSize += 1 /* encryption method */
+		}
+		if flags&v4FlagDataLengthIndicator != 0 {
+			headerSize += 4 /* data length indicator */
+		}
+	}
+	if len(data) < headerSize {
+		return nil, ErrInvalidLength
+	}
+	data = data[headerSize:]
+	if encrypted {
+		return nil, ErrEncryptedFrame
+	}
+	if version == 4 && flags&v4FlagUnsynchronisation != 0 {
+		data = resynchronise(data)
+	}
+	if !compressed {
+		return data, nil
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Bytes encodes the tag without padding and unsynchronisation.
+func (t Tag) Bytes() []byte {
+	version := t.Version
+	if version != 3 {
+		version = 4
+	}
+
+	frames := &bytes.Buffer{}
+	for _, frame := range t.Frames {
+		frames.WriteString(frame.Id)
+		if version == 4 {
+			frames.Write(syncsafeBytes(len(frame.Data)))
+		} else {
+			frames.Write(binary.BigEdian.BytesI32(int32(len(frame.Data))))
+		}
+		frames.Write([]byte{0x0, 0x0} /* flags */)
+		frames.Write(frame.Data)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("ID3")
+	buf.Write([]byte{version, 0x0 /* revision */, 0x0 /* flags */})
+	buf.Write(syncsafeBytes(frames.Len()))
+	buf.Write(frames.Bytes())
+	return buf.Bytes()
+}
+
+// syncsafe decodes 28 bit integer stored in 4 bytes (7 bit per byte).
+func syncsafe(data []byte) int {
+	return int(data[0]&0x7F)<<21 | int(data[1]&0x7F)<<14 | int(data[2]&0x7F)<<7 | int(data[3]&0x7F)
+}
+
+func syncsafeBytes(size int) []byte {
+	return []byte{byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+}
+
+// resynchronise removes 0x00 inserted after 0xFF by the unsynchronisation.
+func resynchronise(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+}
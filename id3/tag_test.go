@@ -0,0 +1,187 @@
+package id3
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+	"github.com/tingtt/qtffilst/udta"
+)
+
+func testTag(version, flags byte, body ...[]byte) []byte {
+	frames := slices.Concat(body...)
+	return slices.Concat([]byte("ID3"), []byte{version, 0, flags}, syncsafeBytes(len(frames)), frames)
+}
+
+func testFrame3(id string, flags byte, data []byte) []byte {
+	return slices.Concat([]byte(id), binary.BigEdian.BytesI32(int32(len(data))), []byte{0, flags}, data)
+}
+
+func testFrame4(id string, flags byte, data []byte) []byte {
+	return slices.Concat([]byte(id), syncsafeBytes(len(data)), []byte{0, flags}, data)
+}
+
+func compress(data []byte) []byte {
+	buf := &bytes.Buffer{}
+	w := zlib.NewWriter(buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestTagRoundTrip(t *testing.T) {
+	frames := []Frame{
+		NewTextFrame("TIT2", "Title"),
+		NewTextFrame("TPE1", "Artist 1", "Artist 2"),
+		NewCommentFrame("COMM", Comment{"eng", "", "Comment"}),
+		NewPictureFrame(Picture{MIMEType: "image/png", Type: PictureTypeFrontCover, Data: []byte{0x89, 'P', 'N', 'G', 0xFF, 0x00}}),
+	}
+	for _, version := range []byte{3, 4} {
+		t.Run(fmt.Sprintf("ID3v2.%d", version), func(t *testing.T) {
+			tag := Tag{version, frames}
+			got, err := Decode(tag.Bytes())
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tag) {
+				t.Errorf("tag = %+v, want %+v", got, tag)
+			}
+		})
+	}
+
+	id32 := ID32{udta.Language(0x2A0E /* jpn */), Tag{4, frames[:1]}}
+	got, err := DecodeID32(id32.Bytes())
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if !reflect.DeepEqual(got, id32) {
+		t.Errorf("ID32 = %+v, want %+v", got, id32)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	title := []byte("\x03Title")
+	tests := []struct {
+		name string
+		data []byte
+		want []Frame
+	}{
+		{
+			name: "ID3v2.3 frame size is not syncsafe",
+			data: testTag(3, 0, testFrame3("TIT2", 0, bytes.Repeat([]byte{'a'}, 200))),
+			want: []Frame{{"TIT2", bytes.Repeat([]byte{'a'}, 200)}},
+		},
+		{
+			name: "ID3v2.3 extended header",
+			data: testTag(3, flagExtendedHeader, []byte{0, 0, 0, 6}, make([]byte, 6), testFrame3("TIT2", 0, title)),
+			want: []Frame{{"TIT2", title}},
+		},
+		{
+			name: "ID3v2.4 extended header",
+			data: testTag(4, flagExtendedHeader, []byte{0, 0, 0, 6, 1, 0}, testFrame4("TIT2", 0, title)),
+			want: []Frame{{"TIT2", title}},
+		},
+		{
+			name: "ID3v2.3 unsynchronisation of the tag",
+			data: testTag(3, flagUnsynchronisation, testFrame3("APIC", 0, []byte{0xFF, 0x00, 0xE0})[:10], []byte{0xFF, 0x00, 0x00, 0xE0}),
+			want: []Frame{{"APIC", []byte{0xFF, 0x00, 0xE0}}},
+		},
+		{
+			name: "ID3v2.4 unsynchronisation of the frame",
+			data: testTag(4, 0, testFrame4("APIC", v4FlagUnsynchronisation, []byte{0xFF, 0x00, 0xE0})),
+			want: []Frame{{"APIC", []byte{0xFF, 0xE0}}},
+		},
+		{
+			name: "ID3v2.3 compressed frame",
+			data: testTag(3, 0, testFrame3("TIT2", v3FlagCompression, slices.Concat([]byte{0, 0, 0, 6}, compress(title)))),
+			want: []Frame{{"TIT2", title}},
+		},
+		{
+			name: "ID3v2.4 compressed frame with data length indicator",
+			data: testTag(4, 0, testFrame4("TIT2", v4FlagCompression|v4FlagDataLengthIndicator, slices.Concat(syncsafeBytes(6), compress(title)))),
+			want: []Frame{{"TIT2", title}},
+		},
+		{
+			name: "encrypted frame is skipped",
+			data: testTag(4, 0, testFrame4("TIT2", v4FlagEncryption, []byte{0x80, 1, 2}), testFrame4("TPE1", 0, title)),
+			want: []Frame{{"TPE1", title}},
+		},
+		{
+			name: "frame of invalid compressed data is skipped",
+			data: testTag(3, 0, testFrame3("TIT2", v3FlagCompression, []byte{0, 0, 0, 6, 1, 2, 3}), testFrame3("TPE1", 0, title)),
+			want: []Frame{{"TPE1", title}},
+		},
+		{
+			name: "frame exceeding the tag ends the frames",
+			data: testTag(4, 0, testFrame4("TIT2", 0, title), testFrame4("TPE1", 0, title)[:12]),
+			want: []Frame{{"TIT2", title}},
+		},
+		{
+			name: "padding ends the frames",
+			data: testTag(4, 0, testFrame4("TIT2", 0, title), make([]byte, 32)),
+			want: []Frame{{"TIT2", title}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.data)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Frames, tt.want) {
+				t.Errorf("frames = %q, want %q", got.Frames, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"not ID3", []byte("ID4\x04\x00\x00\x00\x00\x00\x00"), ErrInvalidHeader},
+		{"shorter than header", []byte("ID3\x04\x00"), ErrInvalidHeader},
+		{"ID3v2.2", testTag(2, 0), ErrUnsupportedVersion},
+		{"size exceeds data", testTag(4, 0, make([]byte, 20))[:20], ErrInvalidLength},
+		{"extended header exceeds tag", testTag(3, flagExtendedHeader, []byte{0, 0, 0, 100}), ErrInvalidLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFrameText(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{"ISO-8859-1", []byte("\x00Caf\xe9"), []string{"Café"}},
+		{"UTF-8 values", []byte("\x03A\x00B"), []string{"A", "B"}},
+		{"UTF-16 little-endian", []byte{EncodingUTF16, 0xFF, 0xFE, 'H', 0, 'i', 0, 0, 0}, []string{"Hi"}},
+		{"UTF-16 big-endian", []byte{EncodingUTF16, 0xFE, 0xFF, 0, 'H', 0, 'i'}, []string{"Hi"}},
+		{"UTF-16 without BOM", []byte{EncodingUTF16BE, 0, 'H', 0, 'i'}, []string{"Hi"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Frame{"TIT2", tt.data}.Text()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package qtffilst
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/tingtt/qtffilst/id3"
+)
+
+// ReadID32 reads the ID3v2 tag of `.moov.udta.meta.ID32`.
+// Returns nil if `ID32` box does not exist.
+func (r *reader) ReadID32() (*id3.ID32, error) {
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return nil, err
+		}
+		if !id32Box(box) {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		err = copy(r.f, box.DataPosition, box.DataSize, buf)
+		if err != nil {
+			return nil, err
+		}
+		id32, err := id3.DecodeID32(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s %w", box.Path, err)
+		}
+		return &id32, nil
+	}
+	return nil, nil
+}
+
+// WriteID32 replaces the ID3v2 tag of `.moov.udta.meta.ID32`, or removes it if id32 is nil.
+// `ID32` box is appended after `.moov.udta.meta.ilst` if it does not exist.
+func (r *readWriter) WriteID32(dest, tmpDest *os.File, id32 *id3.ID32) error {
+	var id32Exists, ilstExists bool
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return err
+		}
+		id32Exists = id32Exists || id32Box(box)
+		ilstExists = ilstExists || box.Path == ".moov.udta.meta.ilst"
+	}
+	if id32 != nil && !id32Exists && !ilstExists {
+		return ErrIlstBoxDoesNotExist
+	}
+
+	var data []byte
+	if id32 != nil {
+		data = id32.Bytes()
+	}
+	written := false
+	for box, err := range WritableWalk(r.f, r.size, tmpDest) {
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case id32Box(box.Box):
+			if id32 == nil || written {
+				_, err = box.Write(nil)
+				slog.Info("remove", slog.String("path", box.Path), slog.String("diff", fmt.Sprintf("%+d", -box.DataSize-8)))
+				break
+			}
+			_, err = box.Write(data)
+			written = true
+			slog.Info("modify", slog.String("path", box.Path), slog.String("diff", fmt.Sprintf("%+d", int32(len(data))-box.DataSize)))
+		case box.Path == ".moov.udta.meta.ilst" && box.IsContainable:
+			if id32 == nil || id32Exists {
+				continue
+			}
+			_, err = box.InsertNewBox("ID32", data)
+			written = true
+			slog.Info("append", slog.String("path", ".moov.udta.meta.ID32"), slog.String("diff", fmt.Sprintf("%+d", len(data)+8)))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.copyWithOffsetsPatch(tmpDest, dest)
+}
@@ -0,0 +1,84 @@
+package ilst
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DateIds are the ids of the items that hold date as text.
+var DateIds = []string{"(c)day", "rldt"}
+
+type DatePrecision int
+
+const (
+	DatePrecisionYear DatePrecision = iota
+	DatePrecisionMonth
+	DatePrecisionDay
+	DatePrecisionTime
+)
+
+// Date is the date of the text items (e.g. `©day`, `rldt`).
+// Text format: "2006", "2006-01", "2006-01-02" or ISO 8601 with time (e.g. "2006-01-02T15:04:05Z")
+type Date struct {
+	Time      time.Time
+	Precision DatePrecision
+	noZone    bool // time is parsed without zone
+}
+
+var dateLayouts = []struct {
+	layout    string
+	precision DatePrecision
+	noZone    bool
+}{
+	{"2006", DatePrecisionYear, false},
+	{"2006-01", DatePrecisionMonth, false},
+	{"2006-01-02", DatePrecisionDay, false},
+	{time.RFC3339Nano, DatePrecisionTime, false},
+	{"2006-01-02T15:04:05", DatePrecisionTime, true},
+	{"2006-01-02T15:04", DatePrecisionTime, true},
+	{"2006-01-02 15:04:05", DatePrecisionTime, true},
+}
+
+func ParseDate(str string) (Date, error) {
+	value := strings.TrimSpace(str)
+	for _, l := range dateLayouts {
+		t, err := time.Parse(l.layout, value)
+		if err == nil {
+			return Date{t, l.precision, l.noZone}, nil
+		}
+	}
+	return Date{}, fmt.Errorf("invalid date (\"%s\")", str)
+}
+
+// String returns the text of the date in the ISO 8601 layout of the precision,
+// keeping the offset of the time (e.g. "2006-01-02T15:04:05+09:00"), or without zone if the time is parsed without zone.
+func (d Date) String() string {
+	switch d.Precision {
+	case DatePrecisionYear:
+		return d.Time.Format("2006")
+	case DatePrecisionMonth:
+		return d.Time.Format("2006-01")
+	case DatePrecisionDay:
+		return d.Time.Format("2006-01-02")
+	default:
+		if d.noZone {
+			return d.Time.Format("2006-01-02T15:04:05.999999999")
+		}
+		return d.Time.Format(time.RFC3339Nano)
+	}
+}
+
+// Date parses the text as Date.
+func (it internationalText) Date() (Date, error) {
+	return ParseDate(it.Text)
+}
+
+func NewDateText(date Date) *internationalText {
+	return NewInternationalText(date.String())
+}
+
+func isDateId(id string) bool {
+	return slices.Contains(DateIds, id)
+}
@@ -0,0 +1,78 @@
+package ilst
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		str       string
+		precision DatePrecision
+		want      string
+	}{
+		{"2012", DatePrecisionYear, "2012"},
+		{" 2012-05 ", DatePrecisionMonth, "2012-05"},
+		{"2012-05-08", DatePrecisionDay, "2012-05-08"},
+		{"2012-05-08T16:00:00+09:00", DatePrecisionTime, "2012-05-08T16:00:00+09:00"},
+		{"2012-05-08T07:00:00Z", DatePrecisionTime, "2012-05-08T07:00:00Z"},
+		{"2012-05-08T16:00:00.5-05:00", DatePrecisionTime, "2012-05-08T16:00:00.5-05:00"},
+		{"2012-05-08T16:00:00", DatePrecisionTime, "2012-05-08T16:00:00"},
+		{"2012-05-08T16:00", DatePrecisionTime, "2012-05-08T16:00:00"},
+		{"2012-05-08 16:00:00", DatePrecisionTime, "2012-05-08T16:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			date, err := ParseDate(tt.str)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if date.Precision != tt.precision {
+				t.Errorf("precision = %d, want %d", date.Precision, tt.precision)
+			}
+			if got := date.String(); got != tt.want {
+				t.Errorf("string = %q, want %q", got, tt.want)
+			}
+
+			// normalized text is parsed to the same date
+			reparsed, err := ParseDate(date.String())
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reparsed.Time.Equal(date.Time) || reparsed.String() != date.String() {
+				t.Errorf("reparsed = %s, want %s", reparsed, date)
+			}
+		})
+	}
+}
+
+func TestParseDateError(t *testing.T) {
+	for _, str := range []string{"", "12", "2012-13", "2012-05-32", "May 8, 2012", "2012-05-08T25:00:00Z"} {
+		t.Run(str, func(t *testing.T) {
+			if date, err := ParseDate(str); err == nil {
+				t.Errorf("date = %s, want error", date)
+			}
+		})
+	}
+}
+
+func TestDateString(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name string
+		date Date
+		want string
+	}{
+		{"year", Date{Time: time.Date(2012, 5, 8, 0, 0, 0, 0, time.UTC), Precision: DatePrecisionYear}, "2012"},
+		{"month", Date{Time: time.Date(2012, 5, 8, 0, 0, 0, 0, time.UTC), Precision: DatePrecisionMonth}, "2012-05"},
+		{"day", Date{Time: time.Date(2012, 5, 8, 0, 0, 0, 0, time.UTC), Precision: DatePrecisionDay}, "2012-05-08"},
+		{"time keeps offset", Date{Time: time.Date(2012, 5, 8, 16, 0, 0, 0, jst), Precision: DatePrecisionTime}, "2012-05-08T16:00:00+09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.date.String(); got != tt.want {
+				t.Errorf("string = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
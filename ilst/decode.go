@@ -1,22 +1,32 @@
 package ilst
 
 import (
+	"errors"
 	"reflect"
 	"strconv"
 	"strings"
 )
 
 type decoder struct {
+	id          string
 	targetField reflect.Value
 }
 
 func (d decoder) Decode(str string) ([]byte, error) {
 	switch d.targetField.Interface().(type) {
 	case *internationalText:
+		if isDateId(d.id) {
+			date, err := ParseDate(str)
+			if err != nil {
+				return nil, err
+			}
+			return NewDateText(date).Bytes()
+		}
 		return NewInternationalText(str).Bytes()
+	case *urlText:
+		return NewURLText(str).Bytes()
 	case *Genre:
-		// TODO: implement decode to Genre
-		panic("unimplemented: decode to Genre")
+		return nil, errors.New("unsupported: decode to Genre from string (use \"genre\" for the genre name)")
 	case *BoolWithHeader0x15_0:
 		b, err := strconv.ParseBool(str)
 		if err != nil {
@@ -28,7 +38,74 @@ func (d decoder) Decode(str string) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		return (&Int16WithHeader0x15_0{int16(i)}).Bytes(), nil
+		return (&Int16WithHeader0x15_0{Value: int16(i)}).Bytes(), nil
+	case *Int32WithHeader0x15_0:
+		i, err := strconv.ParseInt(str, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return (&Int32WithHeader0x15_0{Value: int32(i)}).Bytes(), nil
+	case *Int64WithHeader0x15_0:
+		i, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return (&Int64WithHeader0x15_0{Value: i}).Bytes(), nil
+	case *Rating:
+		r, err := ParseRating(str)
+		if err != nil {
+			return nil, err
+		}
+		return r.Bytes(), nil
+	case *MediaType:
+		m, err := ParseMediaType(str)
+		if err != nil {
+			return nil, err
+		}
+		return m.Bytes(), nil
+	case *AppleStoreAccountType:
+		a, err := ParseAppleStoreAccountType(str)
+		if err != nil {
+			return nil, err
+		}
+		return a.Bytes(), nil
+	case *ITunSMPB:
+		i, err := ParseITunSMPB(str)
+		if err != nil {
+			return nil, err
+		}
+		return i.Bytes(), nil
+	case *SoundCheck:
+		s, err := ParseSoundCheck(str)
+		if err != nil {
+			return nil, err
+		}
+		return s.Bytes(), nil
+	case *ReplayGain:
+		r, err := ParseReplayGain(str)
+		if err != nil {
+			return nil, err
+		}
+		return r.Bytes(), nil
+	case *ReplayGainPeak:
+		r, err := ParseReplayGainPeak(str)
+		if err != nil {
+			return nil, err
+		}
+		return r.Bytes(), nil
+	case *MusicBrainzID:
+		m, err := ParseMusicBrainzID(str)
+		if err != nil {
+			return nil, err
+		}
+		return m.Bytes(), nil
+	case *MusicBrainzIDs:
+		// a `data` box per identifier, appended on SetDecoded
+		m, err := ParseMusicBrainzID(str)
+		if err != nil {
+			return nil, err
+		}
+		return m.Bytes(), nil
 	case *TrackNumber:
 		number, total, err := decodeSlashedStr(str)
 		if err != nil {
@@ -53,6 +130,8 @@ func (d decoder) Decode(str string) ([]byte, error) {
 			total = number
 		}
 		return (&TrackNumber{int16(number), int16(total)}).Bytes()
+	case *CoverArt:
+		return nil, errors.New("unsupported: decode to CoverArt from string")
 	default:
 		panic("unsupported item type")
 	}
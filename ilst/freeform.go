@@ -0,0 +1,139 @@
+package ilst
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+// Box name of freeform items.
+// Freeform item has `mean` and `name` boxes before `data` box,
+// and its id is represented as "----:<mean>:<name>" (e.g. "----:com.apple.iTunes:iTunSMPB").
+const FreeformBoxName = "----"
+
+func NewFreeformId(mean, name string) string {
+	return FreeformBoxName + ":" + mean + ":" + name
+}
+
+func ParseFreeformId(id string) (mean, name string, ok bool) {
+	l := strings.SplitN(id, ":", 3)
+	if len(l) != 3 || l[0] != FreeformBoxName {
+		return "", "", false
+	}
+	return l[1], l[2], true
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/well-known_types
+func utf8TextBytes(text string) []byte {
+	HEADER := []byte{0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0}
+	return append(HEADER, []byte(text)...)
+}
+
+func decodeUTF8Text(data []byte) (string, error) {
+	if len(data) < 8 {
+		return "", ErrInvalidLength
+	}
+	return string(data[8:]), nil
+}
+
+// ITunSMPB is gapless playback information of iTunes.
+// Text format: " 00000000 <encoder delay> <padding> <original sample count> ..." (hex)
+type ITunSMPB struct {
+	EncoderDelay        int32 // priming samples
+	Padding             int32
+	OriginalSampleCount int64
+	reserved            string
+	remaining           []string
+	raw                 string // text that cannot be parsed, kept as is
+}
+
+func ParseITunSMPB(str string) (ITunSMPB, error) {
+	fields := strings.Fields(str)
+	if len(fields) < 4 {
+		return ITunSMPB{}, fmt.Errorf("invalid iTunSMPB (\"%s\")", str)
+	}
+	encoderDelay, err := strconv.ParseUint(fields[1], 16, 32)
+	if err != nil {
+		return ITunSMPB{}, err
+	}
+	padding, err := strconv.ParseUint(fields[2], 16, 32)
+	if err != nil {
+		return ITunSMPB{}, err
+	}
+	originalSampleCount, err := strconv.ParseUint(fields[3], 16, 64)
+	if err != nil {
+		return ITunSMPB{}, err
+	}
+	return ITunSMPB{
+		EncoderDelay:        int32(encoderDelay),
+		Padding:             int32(padding),
+		OriginalSampleCount: int64(originalSampleCount),
+		reserved:            fields[0],
+		remaining:           fields[4:],
+	}, nil
+}
+
+// TotalSampleCount returns the number of samples including encoder delay and padding.
+func (i ITunSMPB) TotalSampleCount() int64 {
+	return int64(i.EncoderDelay) + i.OriginalSampleCount + int64(i.Padding)
+}
+
+// Valid reports whether the text is parsed (false if decoded from the text in unknown format).
+func (i ITunSMPB) Valid() bool {
+	return i.raw == ""
+}
+
+func (i ITunSMPB) String() string {
+	if !i.Valid() {
+		return i.raw
+	}
+	reserved := i.reserved
+	if reserved == "" {
+		reserved = "00000000"
+	}
+	fields := []string{
+		reserved,
+		fmt.Sprintf("%08X", uint32(i.EncoderDelay)),
+		fmt.Sprintf("%08X", uint32(i.Padding)),
+		fmt.Sprintf("%016X", uint64(i.OriginalSampleCount)),
+	}
+	return " " + strings.Join(append(fields, i.remaining...), " ")
+}
+
+// decodeITunSMPB keeps the text as is if it cannot be parsed,
+// so that reading and rewriting the file do not fail by the item written by other tools.
+func decodeITunSMPB(data []byte) (ITunSMPB, error) {
+	text, err := decodeUTF8Text(data)
+	if err != nil {
+		return ITunSMPB{}, err
+	}
+	i, err := ParseITunSMPB(text)
+	if err != nil {
+		return ITunSMPB{raw: text}, nil
+	}
+	return i, nil
+}
+
+func (i ITunSMPB) Bytes() []byte {
+	return utf8TextBytes(i.String())
+}
+
+// FreeformItemHeader returns `mean` and `name` boxes of the freeform item.
+// Returns nil if the id is not freeform item.
+func FreeformItemHeader(id string) []byte {
+	mean, name, ok := ParseFreeformId(id)
+	if !ok {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	for _, box := range []struct{ name, value string }{{"mean", mean}, {"name", name}} {
+		buf.Write(binary.BigEdian.BytesI32(int32(8 + 4 + len(box.value))))
+		buf.Write([]byte(box.name))
+		buf.Write(bytes.Repeat([]byte{0x0}, 4) /* version, flags */)
+		buf.Write([]byte(box.value))
+	}
+	return buf.Bytes()
+}
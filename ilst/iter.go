@@ -53,6 +53,19 @@ func EncodedValues(ilst *ItemList) iter.Seq2[EncodedValue, error] {
 		for i := range make([]interface{}, rt.NumField()) {
 			id := rt.Field(i).Tag.Get("id")
 			value := rv.Field(i).Interface()
+			if multiple, ok := value.(multipleDataValue); ok {
+				// item box has a `data` box per value (e.g. image of `covr`)
+				if reflect.ValueOf(multiple).IsNil() {
+					continue
+				}
+				for _, data := range multiple.dataBytes() {
+					_continue := yield(EncodedValue{id, data}, nil)
+					if !_continue {
+						return
+					}
+				}
+				continue
+			}
 			buf, err := encodeFieldValue(value)
 			if buf == nil {
 				continue
@@ -65,6 +78,11 @@ func EncodedValues(ilst *ItemList) iter.Seq2[EncodedValue, error] {
 	}
 }
 
+// multipleDataValue is implemented by the item value that is stored in multiple `data` boxes.
+type multipleDataValue interface {
+	dataBytes() [][]byte
+}
+
 func encodeFieldValue(value any) ([]byte, error) {
 	switch v := value.(type) {
 	case *internationalText:
@@ -72,6 +90,11 @@ func encodeFieldValue(value any) ([]byte, error) {
 			return nil, nil
 		}
 		return v.Bytes()
+	case *urlText:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes()
 	case *Genre:
 		if v == nil {
 			return nil, nil
@@ -87,6 +110,56 @@ func encodeFieldValue(value any) ([]byte, error) {
 			return nil, nil
 		}
 		return v.Bytes(), nil
+	case *Int32WithHeader0x15_0:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *Int64WithHeader0x15_0:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *Rating:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *MediaType:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *AppleStoreAccountType:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *ITunSMPB:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *SoundCheck:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *ReplayGain:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *ReplayGainPeak:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
+	case *MusicBrainzID:
+		if v == nil {
+			return nil, nil
+		}
+		return v.Bytes(), nil
 	case *TrackNumber:
 		if v == nil {
 			return nil, nil
@@ -111,28 +184,67 @@ func (w writableValue) SetDecoded(buf []byte) (err error) {
 	switch w.field.Interface().(type) {
 	case *internationalText:
 		err = setField(w.field, decodeInternationalText, buf)
+	case *urlText:
+		err = setField(w.field, decodeURLText, buf)
 	case *Genre:
 		err = setField(w.field, decodeGenre, buf)
 	case *BoolWithHeader0x15_0:
 		err = setField(w.field, decodeBoolWithHeader0x15_0, buf)
 	case *Int16WithHeader0x15_0:
-		err = setField(w.field, decodeInt16WithHeader0x15_0, buf)
+		err = setField(w.field, decodeIntWithHeader0x15_0[int16], buf)
+	case *Int32WithHeader0x15_0:
+		err = setField(w.field, decodeIntWithHeader0x15_0[int32], buf)
+	case *Int64WithHeader0x15_0:
+		err = setField(w.field, decodeIntWithHeader0x15_0[int64], buf)
+	case *Rating:
+		err = setField(w.field, decodeRating, buf)
+	case *MediaType:
+		err = setField(w.field, decodeMediaType, buf)
+	case *AppleStoreAccountType:
+		err = setField(w.field, decodeAppleStoreAccountType, buf)
+	case *ITunSMPB:
+		err = setField(w.field, decodeITunSMPB, buf)
+	case *SoundCheck:
+		err = setField(w.field, decodeSoundCheck, buf)
+	case *ReplayGain:
+		err = setField(w.field, decodeReplayGain, buf)
+	case *ReplayGainPeak:
+		err = setField(w.field, decodeReplayGainPeak, buf)
+	case *MusicBrainzID:
+		err = setField(w.field, decodeMusicBrainzID, buf)
 	case *TrackNumber:
 		err = setField(w.field, decodeTrackNumber, buf)
 	case *DiskNumber:
 		err = setField(w.field, decodeDiskNumber, buf)
+	case *CoverArt:
+		err = appendImage(w.field, buf)
+	case *MusicBrainzIDs:
+		err = appendMusicBrainzID(w.field, buf)
 	default:
 		panic("unsupported item type")
 	}
 	return err
 }
 
+// MultipleData reports whether the item of the id can have multiple `data` boxes.
+func MultipleData(id string) bool {
+	rt := reflect.TypeOf(ItemList{})
+
+	for i := range make([]interface{}, rt.NumField()) {
+		f := rt.Field(i)
+		if f.Tag.Get("id") == id {
+			return f.Type.Implements(reflect.TypeFor[multipleDataValue]())
+		}
+	}
+	return false
+}
+
 func (w writableValue) Remove() {
-	w.field.Set(reflect.ValueOf(nil))
+	w.field.Set(reflect.Zero(w.field.Type()))
 }
 
 func (w writableValue) GetDecorder() decoder {
-	return decoder{w.field}
+	return decoder{w.id, w.field}
 }
 
 func IterateFieldWriters(ilst *ItemList) iter.Seq2[string, writableValue] {
@@ -163,3 +275,29 @@ func setField[T any](field reflect.Value, decode func(data []byte) (T, error), d
 	field.Set(reflect.ValueOf(&v))
 	return nil
 }
+
+func appendImage(field reflect.Value, data []byte) error {
+	image, err := decodeImage(data)
+	if err != nil {
+		return err
+	}
+	if field.IsNil() {
+		field.Set(reflect.ValueOf(&CoverArt{}))
+	}
+	coverArt := field.Interface().(*CoverArt)
+	coverArt.Images = append(coverArt.Images, image)
+	return nil
+}
+
+func appendMusicBrainzID(field reflect.Value, data []byte) error {
+	id, err := decodeMusicBrainzID(data)
+	if err != nil {
+		return err
+	}
+	if field.IsNil() {
+		field.Set(reflect.ValueOf(&MusicBrainzIDs{}))
+	}
+	ids := field.Interface().(*MusicBrainzIDs)
+	ids.IDs = append(ids.IDs, id)
+	return nil
+}
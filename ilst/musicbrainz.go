@@ -0,0 +1,89 @@
+package ilst
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MusicBrainzID is the MusicBrainz identifier (UUID) stored as the freeform item.
+// https://picard-docs.musicbrainz.org/en/appendices/tag_mapping.html
+// Text format: "f6f0c7c4-8e0b-4d8f-9a5e-3b8e2b3f4c1d"
+// Text that is not a valid UUID is kept as is on reading (see Valid).
+type MusicBrainzID struct {
+	UUID string
+}
+
+// ParseMusicBrainzID validates the UUID and normalizes it to lower case.
+func ParseMusicBrainzID(str string) (MusicBrainzID, error) {
+	uuid := strings.ToLower(strings.TrimSpace(str))
+	if !validUUID(uuid) {
+		return MusicBrainzID{}, fmt.Errorf("invalid MusicBrainz identifier (\"%s\")", str)
+	}
+	return MusicBrainzID{uuid}, nil
+}
+
+func validUUID(str string) bool {
+	if len(str) != 36 {
+		return false
+	}
+	for i, c := range str {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Valid reports whether the identifier is a valid UUID in lower case.
+func (m MusicBrainzID) Valid() bool {
+	return validUUID(m.UUID)
+}
+
+func (m MusicBrainzID) String() string {
+	return m.UUID
+}
+
+func decodeMusicBrainzID(data []byte) (MusicBrainzID, error) {
+	text, err := decodeUTF8Text(data)
+	if err != nil {
+		return MusicBrainzID{}, err
+	}
+	m, err := ParseMusicBrainzID(text)
+	if err != nil {
+		return MusicBrainzID{text}, nil
+	}
+	return m, nil
+}
+
+func (m MusicBrainzID) Bytes() []byte {
+	return utf8TextBytes(m.UUID)
+}
+
+// MusicBrainzIDs holds multiple MusicBrainz identifiers (e.g. artist ids of the track featuring other artists).
+// Each identifier is stored in its own `data` box.
+type MusicBrainzIDs struct {
+	IDs []MusicBrainzID
+}
+
+func (m MusicBrainzIDs) String() string {
+	uuids := make([]string, 0, len(m.IDs))
+	for _, id := range m.IDs {
+		uuids = append(uuids, id.UUID)
+	}
+	return fmt.Sprintf("{IDs:[%s]}", strings.Join(uuids, " "))
+}
+
+func (m MusicBrainzIDs) dataBytes() [][]byte {
+	data := make([][]byte, 0, len(m.IDs))
+	for _, id := range m.IDs {
+		data = append(data, id.Bytes())
+	}
+	return data
+}
@@ -0,0 +1,42 @@
+package ilst
+
+import (
+	"reflect"
+	"strings"
+)
+
+// PersonalIds are the ids of the store purchase items that identify the purchaser.
+var PersonalIds = []string{"akID", "apID", "ownr", "purd"}
+
+// ResolveId returns the ItemList box id for the given name.
+// The name accepts a box id (e.g. "(c)nam" or "©nam"), a friendly name (e.g. "title")
+// or a field name of ItemList (e.g. "TitleC").
+func ResolveId(name string) (id string, ok bool) {
+	if strings.HasPrefix(name, "©") {
+		name = "(c)" + strings.TrimPrefix(name, "©")
+	}
+	rt := reflect.TypeOf(ItemList{})
+
+	for i := range make([]interface{}, rt.NumField()) {
+		f := rt.Field(i)
+		id := f.Tag.Get("id")
+		if name == id || name == f.Tag.Get("name") || name == f.Name {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// Name returns the friendly name of the ItemList box id.
+// Returns empty string if the id is not supported.
+func Name(id string) string {
+	rt := reflect.TypeOf(ItemList{})
+
+	for i := range make([]interface{}, rt.NumField()) {
+		f := rt.Field(i)
+		if f.Tag.Get("id") == id {
+			return f.Tag.Get("name")
+		}
+	}
+	return ""
+}
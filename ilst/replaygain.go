@@ -0,0 +1,184 @@
+package ilst
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ReplayGain is the gain (dB) of ReplayGain.
+// Text format: "-6.50 dB"
+type ReplayGain struct {
+	Gain float64
+	raw  string // text that cannot be parsed, kept as is
+}
+
+func ParseReplayGain(str string) (ReplayGain, error) {
+	value := strings.TrimSpace(str)
+	if strings.HasSuffix(strings.ToLower(value), "db") {
+		value = strings.TrimSpace(value[:len(value)-2])
+	}
+	gain, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return ReplayGain{}, fmt.Errorf("invalid ReplayGain (\"%s\")", str)
+	}
+	return ReplayGain{Gain: gain}, nil
+}
+
+// Valid reports whether the text is parsed (false if decoded from the text in unknown format).
+func (r ReplayGain) Valid() bool {
+	return r.raw == ""
+}
+
+func (r ReplayGain) String() string {
+	if !r.Valid() {
+		return r.raw
+	}
+	return fmt.Sprintf("%.2f dB", r.Gain)
+}
+
+func decodeReplayGain(data []byte) (ReplayGain, error) {
+	text, err := decodeUTF8Text(data)
+	if err != nil {
+		return ReplayGain{}, err
+	}
+	r, err := ParseReplayGain(text)
+	if err != nil {
+		return ReplayGain{raw: text}, nil
+	}
+	return r, nil
+}
+
+func (r ReplayGain) Bytes() []byte {
+	return utf8TextBytes(r.String())
+}
+
+// ReplayGainPeak is the peak amplitude of ReplayGain (1.0 is full scale).
+// Text format: "0.988553"
+type ReplayGainPeak struct {
+	Peak float64
+	raw  string // text that cannot be parsed, kept as is
+}
+
+func ParseReplayGainPeak(str string) (ReplayGainPeak, error) {
+	peak, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil {
+		return ReplayGainPeak{}, fmt.Errorf("invalid ReplayGain peak (\"%s\")", str)
+	}
+	return ReplayGainPeak{Peak: peak}, nil
+}
+
+// Valid reports whether the text is parsed (false if decoded from the text in unknown format).
+func (r ReplayGainPeak) Valid() bool {
+	return r.raw == ""
+}
+
+func (r ReplayGainPeak) String() string {
+	if !r.Valid() {
+		return r.raw
+	}
+	return fmt.Sprintf("%.6f", r.Peak)
+}
+
+func decodeReplayGainPeak(data []byte) (ReplayGainPeak, error) {
+	text, err := decodeUTF8Text(data)
+	if err != nil {
+		return ReplayGainPeak{}, err
+	}
+	r, err := ParseReplayGainPeak(text)
+	if err != nil {
+		return ReplayGainPeak{raw: text}, nil
+	}
+	return r, nil
+}
+
+func (r ReplayGainPeak) Bytes() []byte {
+	return utf8TextBytes(r.String())
+}
+
+// SoundCheck is the volume normalization information of iTunes (iTunNORM).
+// Text format: 10 hex values, missing values are read as 0 (e.g. " 00000A2B 00000A2B 00003C3E 00003C3E 00000000 00000000 00007FFF 00007FFF 00000000 00000000")
+//
+//	[0], [1]: adjustment (1/1000 W) of left and right channels
+//	[2], [3]: adjustment (1/2500 W) of left and right channels
+//	[6], [7]: peak of left and right channels (max 0x7FFF)
+type SoundCheck struct {
+	Values [10]uint32
+	raw    string // text that cannot be parsed, kept as is
+}
+
+// NewSoundCheck converts ReplayGain gain (dB) and peak to SoundCheck.
+func NewSoundCheck(gain ReplayGain, peak ReplayGainPeak) SoundCheck {
+	adjustment := func(base float64) uint32 {
+		return uint32(min(math.Round(base*math.Pow(10, -gain.Gain/10)), 65534))
+	}
+	peakValue := uint32(min(math.Round(max(peak.Peak, 0)*32767), 32767))
+
+	return SoundCheck{Values: [10]uint32{
+		adjustment(1000), adjustment(1000),
+		adjustment(2500), adjustment(2500),
+		0, 0,
+		peakValue, peakValue,
+		0, 0,
+	}}
+}
+
+func ParseSoundCheck(str string) (SoundCheck, error) {
+	fields := strings.Fields(str)
+	if len(fields) == 0 || len(fields) > 10 {
+		return SoundCheck{}, fmt.Errorf("invalid iTunNORM (\"%s\")", str)
+	}
+	soundCheck := SoundCheck{}
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 16, 32)
+		if err != nil {
+			return SoundCheck{}, err
+		}
+		soundCheck.Values[i] = uint32(value)
+	}
+	return soundCheck, nil
+}
+
+// ReplayGain converts SoundCheck to ReplayGain gain (dB) and peak.
+func (s SoundCheck) ReplayGain() (ReplayGain, ReplayGainPeak) {
+	adjustment := max(s.Values[0], s.Values[1])
+	gain := 0.0
+	if adjustment != 0 {
+		gain = -10 * math.Log10(float64(adjustment)/1000)
+	}
+	peak := float64(max(s.Values[6], s.Values[7])) / 32767
+	return ReplayGain{Gain: gain}, ReplayGainPeak{Peak: peak}
+}
+
+// Valid reports whether the text is parsed (false if decoded from the text in unknown format).
+func (s SoundCheck) Valid() bool {
+	return s.raw == ""
+}
+
+func (s SoundCheck) String() string {
+	if !s.Valid() {
+		return s.raw
+	}
+	buf := &strings.Builder{}
+	for _, value := range s.Values {
+		fmt.Fprintf(buf, " %08X", value)
+	}
+	return buf.String()
+}
+
+func decodeSoundCheck(data []byte) (SoundCheck, error) {
+	text, err := decodeUTF8Text(data)
+	if err != nil {
+		return SoundCheck{}, err
+	}
+	s, err := ParseSoundCheck(text)
+	if err != nil {
+		return SoundCheck{raw: text}, nil
+	}
+	return s, nil
+}
+
+func (s SoundCheck) Bytes() []byte {
+	return utf8TextBytes(s.String())
+}
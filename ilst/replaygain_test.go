@@ -0,0 +1,130 @@
+package ilst
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSoundCheckReplayGainRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		gain float64
+		peak float64
+	}{
+		{"attenuation", -6.5, 0.988553},
+		{"no adjustment", 0, 0.5},
+		{"amplification", 3.25, 0.25},
+		{"full scale peak", -1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			soundCheck := NewSoundCheck(ReplayGain{Gain: tt.gain}, ReplayGainPeak{Peak: tt.peak})
+			gain, peak := soundCheck.ReplayGain()
+			if math.Abs(gain.Gain-tt.gain) > 0.01 {
+				t.Errorf("gain = %f, want %f", gain.Gain, tt.gain)
+			}
+			if math.Abs(peak.Peak-tt.peak) > 1.0/32767 {
+				t.Errorf("peak = %f, want %f", peak.Peak, tt.peak)
+			}
+
+			parsed, err := ParseSoundCheck(soundCheck.String())
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if parsed.Values != soundCheck.Values {
+				t.Errorf("parsed = %v, want %v", parsed.Values, soundCheck.Values)
+			}
+		})
+	}
+}
+
+func TestNewSoundCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		gain float64
+		peak float64
+		want [10]uint32
+	}{
+		{"-6.5 dB", -6.5, 0.988553, [10]uint32{4467, 4467, 11167, 11167, 0, 0, 32392, 32392, 0, 0}},
+		{"adjustment is clamped", -50, 0.5, [10]uint32{65534, 65534, 65534, 65534, 0, 0, 16384, 16384, 0, 0}},
+		{"peak over full scale is clamped", 0, 1.5, [10]uint32{1000, 1000, 2500, 2500, 0, 0, 32767, 32767, 0, 0}},
+		{"negative peak is clamped", 0, -1, [10]uint32{1000, 1000, 2500, 2500, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewSoundCheck(ReplayGain{Gain: tt.gain}, ReplayGainPeak{Peak: tt.peak}); got.Values != tt.want {
+				t.Errorf("values = %v, want %v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSoundCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		want    [10]uint32
+		wantErr bool
+	}{
+		{"10 values", " 00000A2B 00000A2B 00003C3E 00003C3E 00000000 00000000 00007FFF 00007FFF 00000000 00000000", [10]uint32{0xA2B, 0xA2B, 0x3C3E, 0x3C3E, 0, 0, 0x7FFF, 0x7FFF, 0, 0}, false},
+		{"missing values are 0", " 00000A2B 00000A2B", [10]uint32{0xA2B, 0xA2B}, false},
+		{"empty", " ", [10]uint32{}, true},
+		{"not hex", " 00000A2B ZZ", [10]uint32{}, true},
+		{"more than 10 values", " 0 0 0 0 0 0 0 0 0 0 0", [10]uint32{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSoundCheck(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got.Values != tt.want {
+				t.Errorf("values = %v, want %v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplayGain(t *testing.T) {
+	tests := []struct {
+		str     string
+		want    string
+		wantErr bool
+	}{
+		{"-6.50 dB", "-6.50 dB", false},
+		{" +3 dB ", "3.00 dB", false},
+		{"1.234DB", "1.23 dB", false},
+		{"0", "0.00 dB", false},
+		{"loud", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, err := ParseReplayGain(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("string = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMalformedReplayGainKeepsText(t *testing.T) {
+	for _, text := range []string{"loud", " 00000A2B ZZ"} {
+		t.Run(text, func(t *testing.T) {
+			gain, err := decodeReplayGain(utf8TextBytes(text))
+			if err != nil || gain.Valid() || gain.String() != text {
+				t.Errorf("ReplayGain = %+v, %v, want invalid %q", gain, err, text)
+			}
+			soundCheck, err := decodeSoundCheck(utf8TextBytes(text))
+			if err != nil || soundCheck.Valid() || soundCheck.String() != text {
+				t.Errorf("SoundCheck = %+v, %v, want invalid %q", soundCheck, err, text)
+			}
+			peak, err := decodeReplayGainPeak(utf8TextBytes(text))
+			if err != nil || peak.Valid() || string(peak.Bytes()) != string(utf8TextBytes(text)) {
+				t.Errorf("ReplayGainPeak = %+v, %v, want invalid %q", peak, err, text)
+			}
+		})
+	}
+}
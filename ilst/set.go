@@ -6,7 +6,13 @@ import (
 	"github.com/tingtt/iterutil"
 )
 
+// SetDecoded sets the value to the field.
+// The id accepts a box id, a friendly name or a field name of ItemList (see ResolveId).
 func (il *ItemList) SetDecoded(id string, value []byte) error {
+	id, ok := ResolveId(id)
+	if !ok {
+		return errors.New("field not found")
+	}
 	for _, v := range iterutil.FilterKey(IterateFieldWriters(il), id) {
 		return v.SetDecoded(value)
 	}
@@ -3,6 +3,10 @@ package ilst
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/tingtt/qtffilst/internal/binary"
 )
@@ -11,11 +15,29 @@ var (
 	ErrInvalidLength = errors.New("invalid length")
 )
 
+// Handler type of `.moov.udta.meta.hdlr` for ItemList.
+const HandlerType = "mdir"
+
 // https://exiftool.org/TagNames/QuickTime.html#ItemList
 // https://developer.apple.com/documentation/quicktime-file-format/user_data_atoms#Media-characteristic-tags
 // Commented out fields are not supported
+// (3GPP asset information boxes `albm`, `auth`, `dscp`, `perf`, `titl` and `yrrc` directly under `.moov.udta` are supported by udta.Assets)
 type ItemList struct {
-	// iTunesInfo	 `id:"----"` // QuickTime iTunesInfo Tags //? Unsupported
+	// iTunesInfo	 `id:"----"` // QuickTime iTunesInfo Tags (see FreeformBoxName)
+	GaplessInfo         *ITunSMPB       `id:"----:com.apple.iTunes:iTunSMPB" name:"gapless_info"`
+	SoundCheck          *SoundCheck     `id:"----:com.apple.iTunes:iTunNORM" name:"soundcheck"`
+	ReplayGainTrackGain *ReplayGain     `id:"----:com.apple.iTunes:replaygain_track_gain" name:"replaygain_track_gain"`
+	ReplayGainTrackPeak *ReplayGainPeak `id:"----:com.apple.iTunes:replaygain_track_peak" name:"replaygain_track_peak"`
+	ReplayGainAlbumGain *ReplayGain     `id:"----:com.apple.iTunes:replaygain_album_gain" name:"replaygain_album_gain"`
+	ReplayGainAlbumPeak *ReplayGainPeak `id:"----:com.apple.iTunes:replaygain_album_peak" name:"replaygain_album_peak"`
+	// MusicBrainz identifiers (Picard's mapping for MP4)
+	MusicBrainzRecordingID    *MusicBrainzID  `id:"----:com.apple.iTunes:MusicBrainz Track Id" name:"musicbrainz_recordingid"`
+	MusicBrainzTrackID        *MusicBrainzID  `id:"----:com.apple.iTunes:MusicBrainz Release Track Id" name:"musicbrainz_trackid"`
+	MusicBrainzAlbumID        *MusicBrainzID  `id:"----:com.apple.iTunes:MusicBrainz Album Id" name:"musicbrainz_albumid"`
+	MusicBrainzReleaseGroupID *MusicBrainzID  `id:"----:com.apple.iTunes:MusicBrainz Release Group Id" name:"musicbrainz_releasegroupid"`
+	MusicBrainzArtistID       *MusicBrainzIDs `id:"----:com.apple.iTunes:MusicBrainz Artist Id" name:"musicbrainz_artistid"`
+	MusicBrainzAlbumArtistID  *MusicBrainzIDs `id:"----:com.apple.iTunes:MusicBrainz Album Artist Id" name:"musicbrainz_albumartistid"`
+	MusicBrainzWorkID         *MusicBrainzIDs `id:"----:com.apple.iTunes:MusicBrainz Work Id" name:"musicbrainz_workid"`
 	// ParentShortTitle      *string                `id:"@PST"`
 	// ParentProductID       *string                `id:"@ppi"`
 	// ParentTitle           *string                `id:"@pti"`
@@ -25,24 +47,24 @@ type ItemList struct {
 	// UnknownCDET           *string                `id:"CDET"`
 	// GUID                  *string                `id:"GUID"`
 	// ProductVersion        *string                `id:"VERS"`
-	AlbumArtist *internationalText `id:"aART"`
-	// AppleStoreAccountType *AppleStoreAccountType `id:"akID"`
+	AlbumArtist           *internationalText     `id:"aART" name:"album_artist"`
+	AppleStoreAccountType *AppleStoreAccountType `id:"akID" name:"store_account_type"`
 	// Album                 *string                `id:"albm"`
-	// AppleStoreAccount     *string                `id:"apID"`
-	ArtistID *Int16WithHeader0x15_0 `id:"atID"`
+	AppleStoreAccount *internationalText     `id:"apID" name:"store_account"`
+	ArtistID          *Int64WithHeader0x15_0 `id:"atID" name:"artist_id"`
 	// Author                *string                `id:"auth"`
-	// Category              *string                `id:"catg"`
-	// ComposerID            *string                `id:"cmID"`
-	// AppleStoreCatalogID   *int32                 `id:"cnID"`
-	// CoverArt              *string                `id:"covr"`
-	Compilation *BoolWithHeader0x15_0 `id:"cpil"`
-	Copyright   *internationalText    `id:"cprt"`
-	Description *internationalText    `id:"desc"`
-	DiskNumber  *DiskNumber           `id:"disk"`
-	// Description           string `id:"dscp"` //? Unsupported
-	// EpisodeGlobalUniqueID *string               `id:"egid"`
-	// GenreID               *int32                `id:"geID"` // QuickTime GenreID Values
-	Genre *Genre `id:"gnre"`
+	Category            *internationalText     `id:"catg" name:"category"`
+	ComposerID          *Int64WithHeader0x15_0 `id:"cmID" name:"composer_id"`
+	AppleStoreCatalogID *Int64WithHeader0x15_0 `id:"cnID" name:"store_catalog_id"`
+	CoverArt            *CoverArt              `id:"covr" name:"cover"`
+	Compilation         *BoolWithHeader0x15_0  `id:"cpil" name:"compilation"`
+	Copyright           *internationalText     `id:"cprt" name:"copyright"`
+	Description         *internationalText     `id:"desc" name:"description"`
+	DiskNumber          *DiskNumber            `id:"disk" name:"disc"`
+	// Description           string `id:"dscp"` // see udta.Assets
+	EpisodeGlobalUniqueID *internationalText     `id:"egid" name:"episode_guid"`   // GUID (UTF-8)
+	GenreID               *Int64WithHeader0x15_0 `id:"geID" name:"store_genre_id"` // QuickTime GenreID Values
+	Genre                 *Genre                 `id:"gnre" name:"genre_id"`
 	// Grouping              *string               `id:"grup"`
 	// GoogleHostHeader      *string               `id:"gshh"`
 	// GooglePingMessage     *string               `id:"gspm"`
@@ -52,74 +74,74 @@ type ItemList struct {
 	// GoogleTrackDuration   *string               `id:"gstd"`
 	// HDVideo               *bool                 `id:"hdvd"`
 	// ITunesU               *bool                 `id:"itnu"`
-	// Keyword               *string               `id:"keyw"`
-	// LongDescription       *string               `id:"ldes"`
-	// Owner                 *string               `id:"ownr"`
-	// Podcast               *bool                 `id:"pcst"`
+	Keyword         *internationalText    `id:"keyw" name:"keyword"`
+	LongDescription *internationalText    `id:"ldes" name:"long_description"`
+	Owner           *internationalText    `id:"ownr" name:"owner"`
+	Podcast         *BoolWithHeader0x15_0 `id:"pcst" name:"podcast"`
 	// Performer             *string               `id:"perf"`
-	DisableInsertPlayGap *BoolWithHeader0x15_0 `id:"pgap"`
-	// AlbumID	int32[2]	  `id:"plID"` //? Unsupported, because I can’t understand document.
+	DisableInsertPlayGap *BoolWithHeader0x15_0  `id:"pgap" name:"gapless"`
+	AlbumID              *Int64WithHeader0x15_0 `id:"plID" name:"album_id"`
 	// ProductID    *string `id:"prID"`
-	// PurchaseDate *string `id:"purd"`
-	// PodcastURL   *string `id:"purl"`
+	PurchaseDate *internationalText `id:"purd" name:"purchase_date"`
+	PodcastURL   *urlText           `id:"purl" name:"podcast_url"`
 	// RatingPercent     *string    `id:"rate"`  //? Unsupported
-	ReleaseDate *internationalText `id:"rldt"`
-	// Rating            *Rating                `id:"rate"`
+	ReleaseDate *internationalText `id:"rldt" name:"release_date"`
+	Rating      *Rating            `id:"rtng" name:"rating"`
 	// StoreDescription  *string                `id:"sdes"`
-	// AppleStoreCountry *int32                 `id:"sfID"` // QuickTime AppleStoreCountry Values
-	// ShowMovement      *bool                  `id:"shwm"`
+	AppleStoreCountry *Int64WithHeader0x15_0 `id:"sfID" name:"store_country"` // QuickTime AppleStoreCountry Values
+	ShowMovement      *BoolWithHeader0x15_0  `id:"shwm" name:"show_movement"`
 	// PreviewImage      *string                `id:"snal"`
-	SortAlbumArtist *internationalText `id:"soaa"`
-	SortAlbum       *internationalText `id:"soal"`
-	SortArtist      *internationalText `id:"soar"`
-	SortComposer    *internationalText `id:"soco"`
-	SortName        *internationalText `id:"sonm"`
-	SortShow        *internationalText `id:"sosn"`
-	// MediaType         *MediaType             `id:"stik"`
+	SortAlbumArtist *internationalText `id:"soaa" name:"sort_album_artist"`
+	SortAlbum       *internationalText `id:"soal" name:"sort_album"`
+	SortArtist      *internationalText `id:"soar" name:"sort_artist"`
+	SortComposer    *internationalText `id:"soco" name:"sort_composer"`
+	SortName        *internationalText `id:"sonm" name:"sort_title"`
+	SortShow        *internationalText `id:"sosn" name:"sort_show"`
+	MediaKind       *MediaType         `id:"stik" name:"media_kind"`
 	// Title             *string                `id:"titl"`
-	BeatsPerMinute *Int16WithHeader0x15_0 `id:"tmpo"`
+	BeatsPerMinute *Int16WithHeader0x15_0 `id:"tmpo" name:"bpm"`
 	// ThumbnailImage    *string                `id:"tnal"`
-	TrackNumber *TrackNumber `id:"trkn"`
-	// TVEpisodeID       *string            `id:"tven"`
-	// TVEpisode         *int32             `id:"tves"`
-	// TVNetworkName     *string            `id:"tvnn"`
-	// TVShow            *string            `id:"tvsh"`
-	// TVSeason          *int32             `id:"tvsn"`
-	// ISRC              *string            `id:"xid "`
+	TrackNumber   *TrackNumber           `id:"trkn" name:"track"`
+	TVEpisodeID   *internationalText     `id:"tven" name:"tv_episode_id"`
+	TVEpisode     *Int32WithHeader0x15_0 `id:"tves" name:"tv_episode"`
+	TVNetworkName *internationalText     `id:"tvnn" name:"tv_network"`
+	TVShow        *internationalText     `id:"tvsh" name:"tv_show"`
+	TVSeason      *Int32WithHeader0x15_0 `id:"tvsn" name:"tv_season"`
+	ISRC          *internationalText     `id:"xid " name:"isrc"`
 	// Year              *string            `id:"yrrc"`
-	Artist            *internationalText `id:"(c)ART"`
-	AlbumC            *internationalText `id:"(c)alb"`
-	ArtDirector       *internationalText `id:"(c)ard"`
-	Arranger          *internationalText `id:"(c)arg"`
-	AuthorC           *internationalText `id:"(c)aut"`
-	Comment           *internationalText `id:"(c)cmt"`
-	ComposerC         *internationalText `id:"(c)com"`
-	Conductor         *internationalText `id:"(c)con"`
-	CopyrightC        *internationalText `id:"(c)cpy"`
-	ContentCreateDate *internationalText `id:"(c)day"`
-	DescriptionC      *internationalText `id:"(c)des"`
-	Director          *internationalText `id:"(c)dir"`
-	EncodedBy         *internationalText `id:"(c)enc"`
-	GenreC            *internationalText `id:"(c)gen"`
-	GroupingC         *internationalText `id:"(c)grp"`
-	Lyrics            *internationalText `id:"(c)lyr"`
-	// MovementCount     *int16             `id:"(c)mvc"`
-	// MovementNumber    *int16             `id:"(c)mvi"`
-	MovementName      *internationalText `id:"(c)mvn"`
-	TitleC            *internationalText `id:"(c)nam"`
-	Narrator          *internationalText `id:"(c)nrt"`
-	OriginalArtist    *internationalText `id:"(c)ope"`
-	Producer          *internationalText `id:"(c)prd"`
-	Publisher         *internationalText `id:"(c)pub"`
-	SoundEngineer     *internationalText `id:"(c)sne"`
-	Soloist           *internationalText `id:"(c)sol"`
-	Subtitle          *internationalText `id:"(c)st3"`
-	Encoder           *internationalText `id:"(c)too"`
-	Track             *internationalText `id:"(c)trk"`
-	Work              *internationalText `id:"(c)wrk"`
-	ComposerCWRT      *internationalText `id:"(c)wrt"`
-	ExecutiveProducer *internationalText `id:"(c)xpd"`
-	GPSCoordinates    *internationalText `id:"(c)xyz"`
+	Artist            *internationalText     `id:"(c)ART" name:"artist"`
+	AlbumC            *internationalText     `id:"(c)alb" name:"album"`
+	ArtDirector       *internationalText     `id:"(c)ard" name:"art_director"`
+	Arranger          *internationalText     `id:"(c)arg" name:"arranger"`
+	AuthorC           *internationalText     `id:"(c)aut" name:"author"`
+	Comment           *internationalText     `id:"(c)cmt" name:"comment"`
+	ComposerC         *internationalText     `id:"(c)com" name:"composer"`
+	Conductor         *internationalText     `id:"(c)con" name:"conductor"`
+	CopyrightC        *internationalText     `id:"(c)cpy" name:"copyright_c"`
+	ContentCreateDate *internationalText     `id:"(c)day" name:"year"`
+	DescriptionC      *internationalText     `id:"(c)des" name:"description_c"`
+	Director          *internationalText     `id:"(c)dir" name:"director"`
+	EncodedBy         *internationalText     `id:"(c)enc" name:"encoded_by"`
+	GenreC            *internationalText     `id:"(c)gen" name:"genre"`
+	GroupingC         *internationalText     `id:"(c)grp" name:"grouping"`
+	Lyrics            *internationalText     `id:"(c)lyr" name:"lyrics"`
+	MovementCount     *Int16WithHeader0x15_0 `id:"(c)mvc" name:"movement_count"`
+	MovementNumber    *Int16WithHeader0x15_0 `id:"(c)mvi" name:"movement_number"`
+	MovementName      *internationalText     `id:"(c)mvn" name:"movement_name"`
+	TitleC            *internationalText     `id:"(c)nam" name:"title"`
+	Narrator          *internationalText     `id:"(c)nrt" name:"narrator"`
+	OriginalArtist    *internationalText     `id:"(c)ope" name:"original_artist"`
+	Producer          *internationalText     `id:"(c)prd" name:"producer"`
+	Publisher         *internationalText     `id:"(c)pub" name:"publisher"`
+	SoundEngineer     *internationalText     `id:"(c)sne" name:"sound_engineer"`
+	Soloist           *internationalText     `id:"(c)sol" name:"soloist"`
+	Subtitle          *internationalText     `id:"(c)st3" name:"subtitle"`
+	Encoder           *internationalText     `id:"(c)too" name:"encoder"`
+	Track             *internationalText     `id:"(c)trk" name:"track_name"`
+	Work              *internationalText     `id:"(c)wrk" name:"work"`
+	ComposerCWRT      *internationalText     `id:"(c)wrt" name:"writer"`
+	ExecutiveProducer *internationalText     `id:"(c)xpd" name:"executive_producer"`
+	GPSCoordinates    *internationalText     `id:"(c)xyz" name:"gps_coordinates"`
 }
 
 // https://developer.apple.com/documentation/quicktime-file-format/user_data_atoms#User-data-text-strings-and-language-codes
@@ -157,6 +179,21 @@ func decodeInternationalText(data []byte) (internationalText, error) {
 	}, nil
 }
 
+// Text returns the text of the text item value.
+func Text(value any) (string, bool) {
+	switch v := value.(type) {
+	case *internationalText:
+		if v != nil {
+			return v.Text, true
+		}
+	case *urlText:
+		if v != nil {
+			return v.Text, true
+		}
+	}
+	return "", false
+}
+
 func (it internationalText) Bytes() ([]byte, error) {
 	buf := &bytes.Buffer{}
 	if it.size == 0 {
@@ -177,38 +214,166 @@ func (it internationalText) Bytes() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-type (
-	AppleStoreAccountType = int8
-	Rating                = int8
-	MediaType             = int8
-)
+// urlText is the text written with the well-known type URL (15) instead of UTF-8 (1).
+type urlText internationalText
+
+func NewURLText(text string) *urlText {
+	return &urlText{
+		size:         15,
+		Text:         text,
+		LanguageCode: 0,
+	}
+}
+
+func decodeURLText(data []byte) (urlText, error) {
+	it, err := decodeInternationalText(data)
+	return urlText(it), err
+}
+
+func (ut urlText) Bytes() ([]byte, error) {
+	if ut.size == 0 {
+		ut.size = 15
+	}
+	return internationalText(ut).Bytes()
+}
+
+type AppleStoreAccountType int8
 
 const (
 	AppleStoreAccountTypeITunes AppleStoreAccountType = iota
 	AppleStoreAccountTypeAOL
 )
 
+var appleStoreAccountTypeNames = map[AppleStoreAccountType]string{
+	AppleStoreAccountTypeITunes: "iTunes",
+	AppleStoreAccountTypeAOL:    "AOL",
+}
+
+func (a AppleStoreAccountType) String() string {
+	if name, ok := appleStoreAccountTypeNames[a]; ok {
+		return name
+	}
+	return strconv.Itoa(int(a))
+}
+
+// ParseAppleStoreAccountType parses the name (e.g. "iTunes") or the number of AppleStoreAccountType.
+func ParseAppleStoreAccountType(str string) (AppleStoreAccountType, error) {
+	value, err := parseEnum(str, appleStoreAccountTypeNames)
+	return AppleStoreAccountType(value), err
+}
+
+func decodeAppleStoreAccountType(data []byte) (AppleStoreAccountType, error) {
+	value, err := decodeIntWithHeader0x15_0[int8](data)
+	return AppleStoreAccountType(value.Value), err
+}
+
+func (a AppleStoreAccountType) Bytes() []byte {
+	return Int8WithHeader0x15_0{Value: int8(a)}.Bytes()
+}
+
+type Rating int8
+
 const (
 	RatingNone Rating = iota
 	RatingExplicit
 	RatingClean
-	RatingExplicitOld = 4
+	RatingExplicitOld Rating = 4
 )
 
+var ratingNames = map[Rating]string{
+	RatingNone:        "None",
+	RatingExplicit:    "Explicit",
+	RatingClean:       "Clean",
+	RatingExplicitOld: "Explicit (old)",
+}
+
+func (r Rating) String() string {
+	if name, ok := ratingNames[r]; ok {
+		return name
+	}
+	return strconv.Itoa(int(r))
+}
+
+// ParseRating parses the name (e.g. "Explicit") or the number of Rating.
+func ParseRating(str string) (Rating, error) {
+	value, err := parseEnum(str, ratingNames)
+	return Rating(value), err
+}
+
+func decodeRating(data []byte) (Rating, error) {
+	value, err := decodeIntWithHeader0x15_0[int8](data)
+	return Rating(value.Value), err
+}
+
+func (r Rating) Bytes() []byte {
+	return Int8WithHeader0x15_0{Value: int8(r)}.Bytes()
+}
+
+type MediaType int8
+
 const (
 	MediaTypeMovieOld MediaType = iota
 	MediaTypeNormalMusic
 	MediaTypeAudiobook
-	MediaTypeWhackedBookmark = 5
-	MediaTypeMusicVideo      = 6
-	MediaTypeMovie           = 9
-	MediaTypeTVShow          = 10
-	MediaTypeBooklet         = 11
-	MediaTypeRingtone        = 14
-	MediaTypePodcast         = 21
-	MediaTypeiTunesU         = 23
+	MediaTypeWhackedBookmark MediaType = 5
+	MediaTypeMusicVideo      MediaType = 6
+	MediaTypeMovie           MediaType = 9
+	MediaTypeTVShow          MediaType = 10
+	MediaTypeBooklet         MediaType = 11
+	MediaTypeRingtone        MediaType = 14
+	MediaTypePodcast         MediaType = 21
+	MediaTypeiTunesU         MediaType = 23
 )
 
+var mediaTypeNames = map[MediaType]string{
+	MediaTypeMovieOld:        "Movie (old)",
+	MediaTypeNormalMusic:     "Normal (Music)",
+	MediaTypeAudiobook:       "Audiobook",
+	MediaTypeWhackedBookmark: "Whacked Bookmark",
+	MediaTypeMusicVideo:      "Music Video",
+	MediaTypeMovie:           "Movie",
+	MediaTypeTVShow:          "TV Show",
+	MediaTypeBooklet:         "Booklet",
+	MediaTypeRingtone:        "Ringtone",
+	MediaTypePodcast:         "Podcast",
+	MediaTypeiTunesU:         "iTunes U",
+}
+
+func (m MediaType) String() string {
+	if name, ok := mediaTypeNames[m]; ok {
+		return name
+	}
+	return strconv.Itoa(int(m))
+}
+
+// ParseMediaType parses the name (e.g. "Audiobook") or the number of MediaType.
+func ParseMediaType(str string) (MediaType, error) {
+	value, err := parseEnum(str, mediaTypeNames)
+	return MediaType(value), err
+}
+
+func decodeMediaType(data []byte) (MediaType, error) {
+	value, err := decodeIntWithHeader0x15_0[int8](data)
+	return MediaType(value.Value), err
+}
+
+func (m MediaType) Bytes() []byte {
+	return Int8WithHeader0x15_0{Value: int8(m)}.Bytes()
+}
+
+func parseEnum[T ~int8](str string, names map[T]string) (T, error) {
+	for value, name := range names {
+		if strings.EqualFold(str, name) {
+			return value, nil
+		}
+	}
+	value, err := strconv.ParseInt(str, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value (\"%s\")", str)
+	}
+	return T(value), nil
+}
+
 type Genre int8
 
 func decodeGenre(data []byte) (Genre, error) {
@@ -334,28 +499,190 @@ func (tn DiskNumber) Bytes() ([]byte, error) {
 	return append(HEADER, valueBuf.Bytes()...), nil
 }
 
-type Int16WithHeader0x15_0 struct {
-	Value int16
+// https://developer.apple.com/documentation/quicktime-file-format/well-known_types
+type ImageFormat int32
+
+const (
+	ImageFormatJPEG ImageFormat = 13
+	ImageFormatPNG  ImageFormat = 14
+	ImageFormatBMP  ImageFormat = 27
+)
+
+var ErrUnsupportedImageFormat = errors.New("unsupported image format")
+
+// DetectImageFormat detects the image format by magic bytes.
+func DetectImageFormat(data []byte) (ImageFormat, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return ImageFormatJPEG, nil
+	case bytes.HasPrefix(data, []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}):
+		return ImageFormatPNG, nil
+	case bytes.HasPrefix(data, []byte{'B', 'M'}):
+		return ImageFormatBMP, nil
+	default:
+		return 0, ErrUnsupportedImageFormat
+	}
+}
+
+func (f ImageFormat) Extension() string {
+	switch f {
+	case ImageFormatJPEG:
+		return ".jpg"
+	case ImageFormatPNG:
+		return ".png"
+	case ImageFormatBMP:
+		return ".bmp"
+	default:
+		return ".bin"
+	}
+}
+
+type Image struct {
+	Format ImageFormat
+	Data   []byte
+}
+
+// NewImage creates Image with the format detected by magic bytes.
+func NewImage(data []byte) (Image, error) {
+	format, err := DetectImageFormat(data)
+	if err != nil {
+		return Image{}, err
+	}
+	return Image{format, data}, nil
+}
+
+func decodeImage(data []byte) (Image, error) {
+	if len(data) < 8 {
+		return Image{}, ErrInvalidLength
+	}
+	format, err := binary.BigEdian.ReadI32(bytes.NewBuffer(data[:4]))
+	if err != nil {
+		return Image{}, err
+	}
+	return Image{
+		Format: ImageFormat(format & 0x00FFFFFF /* trim type indicator byte */),
+		Data:   data[8:],
+	}, nil
+}
+
+func (i Image) Bytes() []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(binary.BigEdian.BytesI32(int32(i.Format)))
+	buf.Write(bytes.Repeat([]byte{0x0}, 4) /* locale */)
+	buf.Write(i.Data)
+	return buf.Bytes()
+}
+
+// CoverArt holds images of the `data` boxes in `covr` box.
+type CoverArt struct {
+	Images []Image
 }
 
-func decodeInt16WithHeader0x15_0(data []byte) (Int16WithHeader0x15_0, error) {
-	if len(data) < 10 {
-		return Int16WithHeader0x15_0{}, ErrInvalidLength
+func (c CoverArt) String() string {
+	formats := make([]string, 0, len(c.Images))
+	for _, image := range c.Images {
+		formats = append(formats, fmt.Sprintf("%s(%dB)", strings.TrimPrefix(image.Format.Extension(), "."), len(image.Data)))
+	}
+	return fmt.Sprintf("{Images:[%s]}", strings.Join(formats, " "))
+}
+
+func (c CoverArt) dataBytes() [][]byte {
+	data := make([][]byte, 0, len(c.Images))
+	for _, image := range c.Images {
+		data = append(data, image.Bytes())
+	}
+	return data
+}
+
+var ErrValueOverflow = errors.New("value overflows")
+
+// IntWithHeader0x15_0 is big-endian signed integer.
+// Width of the value (1, 2, 4 or 8 bytes) follows the length of the data,
+// and is kept on encoding unless the value does not fit in it.
+type IntWithHeader0x15_0[T int8 | int16 | int32 | int64] struct {
+	Value T
+	width int // bytes (size of T up to 4 bytes if 0)
+}
+
+type (
+	Int8WithHeader0x15_0  = IntWithHeader0x15_0[int8]
+	Int16WithHeader0x15_0 = IntWithHeader0x15_0[int16]
+	Int32WithHeader0x15_0 = IntWithHeader0x15_0[int32]
+	Int64WithHeader0x15_0 = IntWithHeader0x15_0[int64]
+)
+
+func decodeIntWithHeader0x15_0[T int8 | int16 | int32 | int64](data []byte) (IntWithHeader0x15_0[T], error) {
+	if len(data) < 8 {
+		return IntWithHeader0x15_0[T]{}, ErrInvalidLength
+	}
+	valueBuf := bytes.NewBuffer(data[8:])
+	width := valueBuf.Len()
+
+	var (
+		value int64
+		err   error
+	)
+	switch width {
+	case 1:
+		var v int8
+		v, err = binary.BigEdian.ReadI8(bytes.NewBuffer(append([]byte{0x0}, data[8:]...)))
+		value = int64(v)
+	case 2:
+		var v int16
+		v, err = binary.BigEdian.ReadI16(valueBuf)
+		value = int64(v)
+	case 4:
+		var v int32
+		v, err = binary.BigEdian.ReadI32(valueBuf)
+		value = int64(v)
+	case 8:
+		value, err = binary.BigEdian.ReadI64(valueBuf)
+	default:
+		return IntWithHeader0x15_0[T]{}, ErrInvalidLength
 	}
-	value, err := binary.BigEdian.ReadI16(bytes.NewBuffer(data[8:]))
 	if err != nil {
-		return Int16WithHeader0x15_0{}, err
+		return IntWithHeader0x15_0[T]{}, err
+	}
+	if int64(T(value)) != value {
+		return IntWithHeader0x15_0[T]{}, ErrValueOverflow
 	}
 
-	return Int16WithHeader0x15_0{value}, nil
+	return IntWithHeader0x15_0[T]{T(value), width}, nil
 }
 
-func (i Int16WithHeader0x15_0) Bytes() []byte {
+func (i IntWithHeader0x15_0[T]) Bytes() []byte {
 	HEADER := []byte{0x0, 0x0, 0x0, 0x15, 0x0, 0x0, 0x0, 0x0}
-	valueBuf := binary.BigEdian.BytesI16(i.Value)
+	width := i.width
+	if width == 0 {
+		width = min(int(reflect.TypeOf(i.Value).Size()), 4)
+	}
+	for /* widen to fit the value */ width < 8 && !fitsInWidth(int64(i.Value), width) {
+		width *= 2
+	}
+
+	var valueBuf []byte
+	switch width {
+	case 1:
+		valueBuf = binary.BigEdian.BytesI8(int8(i.Value))
+	case 2:
+		valueBuf = binary.BigEdian.BytesI16(int16(i.Value))
+	case 4:
+		valueBuf = binary.BigEdian.BytesI32(int32(i.Value))
+	default:
+		valueBuf = binary.BigEdian.BytesI64(int64(i.Value))
+	}
 	return append(HEADER, valueBuf...)
 }
 
+func fitsInWidth(value int64, width int) bool {
+	bits := uint(width * 8)
+	return value >= -1<<(bits-1) && value < 1<<(bits-1)
+}
+
+func (i IntWithHeader0x15_0[T]) String() string {
+	return fmt.Sprintf("{Value:%d}", i.Value)
+}
+
 type BoolWithHeader0x15_0 struct {
 	Value bool
 }
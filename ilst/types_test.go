@@ -0,0 +1,99 @@
+package ilst
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+var header0x15 = []byte{0x0, 0x0, 0x0, 0x15, 0x0, 0x0, 0x0, 0x0}
+
+func TestIntWithHeader0x15_0RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		value int64
+	}{
+		{"1 byte", slices.Concat(header0x15, []byte{0xFF}), -1},
+		{"2 bytes", slices.Concat(header0x15, []byte{0x01, 0x00}), 256},
+		{"4 bytes", slices.Concat(header0x15, []byte{0x00, 0x00, 0x04, 0xD2}), 1234},
+		{"8 bytes in int32 range", slices.Concat(header0x15, []byte{0, 0, 0, 0, 0, 0, 0x04, 0xD2}), 1234},
+		{"8 bytes over int32 range", slices.Concat(header0x15, []byte{0, 0, 0, 0x01, 0x2A, 0x05, 0xF2, 0x00}), 5000000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeIntWithHeader0x15_0[int64](tt.data)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got.Value != tt.value {
+				t.Errorf("value = %d, want %d", got.Value, tt.value)
+			}
+			if data := got.Bytes(); !bytes.Equal(data, tt.data) {
+				t.Errorf("bytes = %x, want %x", data, tt.data)
+			}
+		})
+	}
+}
+
+func TestIntWithHeader0x15_0Bytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value IntWithHeader0x15_0[int64]
+		want  []byte
+	}{
+		{"new value in 4 bytes", IntWithHeader0x15_0[int64]{Value: 77}, slices.Concat(header0x15, []byte{0, 0, 0, 77})},
+		{"new value widened to 8 bytes", IntWithHeader0x15_0[int64]{Value: -1 << 40}, slices.Concat(header0x15, []byte{0xFF, 0xFF, 0xFF, 0x00, 0, 0, 0, 0})},
+		{"kept width widened to fit", IntWithHeader0x15_0[int64]{Value: 300, width: 1}, slices.Concat(header0x15, []byte{0x01, 0x2C})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.value.Bytes(); !bytes.Equal(got, tt.want) {
+				t.Errorf("bytes = %x, want %x", got, tt.want)
+			}
+		})
+	}
+
+	if got, want := (IntWithHeader0x15_0[int16]{Value: 120}).Bytes(), slices.Concat(header0x15, []byte{0, 120}); !bytes.Equal(got, want) {
+		t.Errorf("int16 bytes = %x, want %x", got, want)
+	}
+}
+
+func TestDecodeIntWithHeader0x15_0Error(t *testing.T) {
+	decodeInt64 := func(data []byte) error { _, err := decodeIntWithHeader0x15_0[int64](data); return err }
+	decodeInt16 := func(data []byte) error { _, err := decodeIntWithHeader0x15_0[int16](data); return err }
+
+	tests := []struct {
+		name    string
+		decode  func(data []byte) error
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "shorter than header",
+			decode:  decodeInt64,
+			data:    header0x15[:4],
+			wantErr: ErrInvalidLength,
+		},
+		{
+			name:    "unsupported width",
+			decode:  decodeInt64,
+			data:    slices.Concat(header0x15, []byte{0, 0, 1}),
+			wantErr: ErrInvalidLength,
+		},
+		{
+			name:    "value overflows int16",
+			decode:  decodeInt16,
+			data:    slices.Concat(header0x15, []byte{0, 1, 0, 0}),
+			wantErr: ErrValueOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.decode(tt.data); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -27,6 +27,12 @@ func (*bigEndian) BytesI32(num int32) []byte {
 	return buf
 }
 
+func (*bigEndian) BytesI64(num int64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(num))
+	return buf
+}
+
 func (*bigEndian) ReadI8(r io.Reader) (int8, error) {
 	buf := make([]byte, 2)
 	_, err := io.ReadFull(r, buf)
@@ -57,6 +63,16 @@ func (*bigEndian) ReadI32(r io.Reader) (int32, error) {
 	return int32(num), nil
 }
 
+func (*bigEndian) ReadI64(r io.Reader) (int64, error) {
+	buf := make([]byte, 8)
+	_, err := io.ReadFull(r, buf)
+	if err != nil {
+		return 0, err
+	}
+	num := binary.BigEndian.Uint64(buf)
+	return int64(num), nil
+}
+
 func Read(r io.Reader, bytes uint) ([]byte, error) {
 	buf := make([]byte, bytes)
 	_, err := io.ReadFull(r, buf)
@@ -0,0 +1,84 @@
+package mdta
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+// Key is the entry of `.moov.meta.keys` box.
+// Keys of the namespace other than KeyNamespace (e.g. "udta") are kept to preserve the key indexes.
+type Key struct {
+	Namespace string
+	Name      string
+}
+
+// DecodeKeys decodes the data of `.moov.meta.keys` box.
+// Index of the `.moov.meta.ilst` item is 1-based index of the keys.
+// https://developer.apple.com/documentation/quicktime-file-format/metadata_item_keys_atom
+func DecodeKeys(data []byte) ([]Key, error) {
+	if len(data) < 8 {
+		return nil, ErrInvalidLength
+	}
+	entryCount, err := binary.BigEdian.ReadI32(bytes.NewReader(data[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []Key{}
+	offset := 8 /* version, flags, entry count */
+	for range uint32(entryCount) {
+		if len(data) < offset+8 {
+			return nil, ErrInvalidLength
+		}
+		size32, err := binary.BigEdian.ReadI32(bytes.NewReader(data[offset:]))
+		if err != nil {
+			return nil, err
+		}
+		size := int(uint32(size32))
+		if size < 8 || len(data) < offset+size {
+			return nil, ErrInvalidLength
+		}
+		keys = append(keys, Key{
+			Namespace: string(data[offset+4 : offset+8]),
+			Name:      string(data[offset+8 : offset+size]),
+		})
+		offset += size
+	}
+	return keys, nil
+}
+
+func EncodeKeys(keys []Key) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(make([]byte, 4) /* version, flags */)
+	buf.Write(binary.BigEdian.BytesI32(int32(len(keys))))
+	for _, key := range keys {
+		buf.Write(binary.BigEdian.BytesI32(int32(8 + len(key.Name))))
+		buf.Write([]byte(key.Namespace))
+		buf.Write([]byte(key.Name))
+	}
+	return buf.Bytes()
+}
+
+// ItemName returns the name of `.moov.meta.ilst` item box of the key index (0-based).
+func ItemName(index int) string {
+	return string(binary.BigEdian.BytesI32(int32(index + 1)))
+}
+
+// KeyIndex returns the key index (0-based) of `.moov.meta.ilst` item box name.
+func KeyIndex(itemName string) (int, error) {
+	if len(itemName) != 4 {
+		return 0, ErrInvalidLength
+	}
+	index32, err := binary.BigEdian.ReadI32(strings.NewReader(itemName))
+	if err != nil {
+		return 0, err
+	}
+	index := int(uint32(index32))
+	if index == 0 {
+		return 0, fmt.Errorf("invalid key index (%d)", index)
+	}
+	return index - 1, nil
+}
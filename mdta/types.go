@@ -0,0 +1,163 @@
+package mdta
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var (
+	ErrInvalidLength = errors.New("invalid length")
+)
+
+// Handler type of `.moov.meta.hdlr` for metadata with keys.
+const HandlerType = "mdta"
+
+// Key namespace of `.moov.meta.keys` entries.
+const KeyNamespace = "mdta"
+
+// https://developer.apple.com/documentation/quicktime-file-format/quicktime_metadata_keys
+const (
+	KeyAlbum             = "com.apple.quicktime.album"
+	KeyArtist            = "com.apple.quicktime.artist"
+	KeyComment           = "com.apple.quicktime.comment"
+	KeyContentIdentifier = "com.apple.quicktime.content.identifier"
+	KeyCreationDate      = "com.apple.quicktime.creationdate"
+	KeyDescription       = "com.apple.quicktime.description"
+	KeyDisplayName       = "com.apple.quicktime.displayname"
+	KeyLocationISO6709   = "com.apple.quicktime.location.ISO6709"
+	KeyMake              = "com.apple.quicktime.make"
+	KeyModel             = "com.apple.quicktime.model"
+	KeySoftware          = "com.apple.quicktime.software"
+	KeyTitle             = "com.apple.quicktime.title"
+)
+
+const keyPrefix = "com.apple.quicktime."
+
+// ResolveKey returns the key for the given name.
+// The name accepts a reverse DNS key (e.g. "com.apple.quicktime.make")
+// or a key without "com.apple.quicktime." prefix (e.g. "make", "location.ISO6709").
+func ResolveKey(name string) string {
+	if strings.Count(name, ".") >= 2 {
+		return name
+	}
+	return keyPrefix + name
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/well-known_types
+type DataType uint32
+
+const (
+	DataTypeUTF8        DataType = 1
+	DataTypeUTF16       DataType = 2
+	DataTypeJPEG        DataType = 13
+	DataTypePNG         DataType = 14
+	DataTypeSignedInt   DataType = 21
+	DataTypeUnsignedInt DataType = 22
+	DataTypeFloat32     DataType = 23
+	DataTypeFloat64     DataType = 24
+)
+
+// Value is the content of `data` box of the metadata item.
+type Value struct {
+	Type   DataType
+	Locale uint32
+	Data   []byte
+}
+
+func NewText(text string) Value {
+	return Value{Type: DataTypeUTF8, Data: []byte(text)}
+}
+
+func DecodeValue(data []byte) (Value, error) {
+	if len(data) < 8 {
+		return Value{}, ErrInvalidLength
+	}
+	r := bytes.NewReader(data)
+	dataType, err := binary.BigEdian.ReadI32(r)
+	if err != nil {
+		return Value{}, err
+	}
+	locale, err := binary.BigEdian.ReadI32(r)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{
+		Type:   DataType(uint32(dataType)),
+		Locale: uint32(locale),
+		Data:   slices.Clone(data[8:]),
+	}, nil
+}
+
+func (v Value) Bytes() []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(binary.BigEdian.BytesI32(int32(v.Type)))
+	buf.Write(binary.BigEdian.BytesI32(int32(v.Locale)))
+	buf.Write(v.Data)
+	return buf.Bytes()
+}
+
+func (v Value) String() string {
+	switch v.Type {
+	case DataTypeUTF8:
+		return string(v.Data)
+	case DataTypeUTF16:
+		if len(v.Data)%2 == 0 {
+			r := bytes.NewReader(v.Data)
+			u := make([]uint16, 0, len(v.Data)/2)
+			for range len(v.Data) / 2 {
+				c, err := binary.BigEdian.ReadI16(r)
+				if err != nil {
+					break
+				}
+				u = append(u, uint16(c))
+			}
+			return string(utf16.Decode(u))
+		}
+	case DataTypeSignedInt:
+		if i, err := readInt(v.Data); err == nil {
+			return fmt.Sprint(i)
+		}
+	case DataTypeUnsignedInt:
+		if i, err := readInt(v.Data); err == nil {
+			return fmt.Sprint(uint64(i) & (math.MaxUint64 >> (64 - 8*len(v.Data))))
+		}
+	case DataTypeFloat32:
+		if i, err := readInt(v.Data); err == nil && len(v.Data) == 4 {
+			return fmt.Sprint(math.Float32frombits(uint32(i)))
+		}
+	case DataTypeFloat64:
+		if i, err := readInt(v.Data); err == nil && len(v.Data) == 8 {
+			return fmt.Sprint(math.Float64frombits(uint64(i)))
+		}
+	}
+	return fmt.Sprintf("(type %d, %dB)", v.Type, len(v.Data))
+}
+
+// readInt reads the big-endian signed integer of 1, 2, 4 or 8 bytes.
+func readInt(data []byte) (int64, error) {
+	r := bytes.NewReader(data)
+	switch len(data) {
+	case 1:
+		return int64(int8(data[0])), nil
+	case 2:
+		i, err := binary.BigEdian.ReadI16(r)
+		return int64(i), err
+	case 4:
+		i, err := binary.BigEdian.ReadI32(r)
+		return int64(i), err
+	case 8:
+		return binary.BigEdian.ReadI64(r)
+	}
+	return 0, ErrInvalidLength
+}
+
+// Metadata is the metadata stored in `.moov.meta` with `keys` box.
+// Item can have multiple `data` boxes (e.g. per locale).
+type Metadata map[string][]Value
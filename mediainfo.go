@@ -0,0 +1,438 @@
+package qtffilst
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var ErrInvalidSampleDescription = errors.New("invalid sample description")
+
+// MediaInfo is the technical information of the movie and its tracks.
+type MediaInfo struct {
+	// Time scale in `.moov.mvhd`
+	Timescale int64
+	// Duration in `.moov.mvhd`
+	Duration int64
+	Tracks   []TrackInfo
+}
+
+// DurationTime returns the duration of the movie.
+func (m MediaInfo) DurationTime() time.Duration {
+	return durationTime(m.Duration, m.Timescale)
+}
+
+// TrackInfo is the technical information of the track.
+// Fields that the sample description does not have are zero.
+type TrackInfo struct {
+	// Track ID in `.moov.trak.tkhd`
+	Id int32
+	// Handler type in `.moov.trak.mdia.hdlr` (e.g. "soun", "vide", "text")
+	HandlerType string
+	// Time scale in `.moov.trak.mdia.mdhd`
+	Timescale int64
+	// Duration in `.moov.trak.mdia.mdhd`
+	Duration int64
+	// Type of the first sample description in `.moov.trak.mdia.minf.stbl.stsd` (e.g. "mp4a", "avc1")
+	Format string
+	// Codec name (e.g. "AAC LC", "ALAC", "H.264"), or empty if unknown
+	Codec string
+
+	// Audio
+	SampleRate int64
+	Channels   int
+	BitDepth   int
+
+	// Video
+	Width  int
+	Height int
+
+	SampleCount int64
+	// Total size of the samples in `.moov.trak.mdia.minf.stbl.stsz`
+	SampleDataSize int64
+}
+
+// DurationTime returns the duration of the track.
+func (t TrackInfo) DurationTime() time.Duration {
+	return durationTime(t.Duration, t.Timescale)
+}
+
+// Bitrate returns the average bitrate (bits per second) computed from the total size of the samples and the duration.
+func (t TrackInfo) Bitrate() int64 {
+	if t.Duration == 0 || t.Timescale == 0 {
+		return 0
+	}
+	return int64(math.Round(float64(t.SampleDataSize) * 8 * float64(t.Timescale) / float64(t.Duration)))
+}
+
+func durationTime(duration, timescale int64) time.Duration {
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(duration/timescale)*time.Second + time.Duration(duration%timescale)*time.Second/time.Duration(timescale)
+}
+
+// ReadMediaInfo reads the technical information from `.moov.mvhd` and the boxes of each track.
+func (r *reader) ReadMediaInfo() (MediaInfo, error) {
+	mediaInfo := MediaInfo{Tracks: []TrackInfo{}}
+	track := TrackInfo{}
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return MediaInfo{}, err
+		}
+
+		switch box.Path {
+		case ".moov.mvhd":
+			// `mvhd` starts with the same fields as `mdhd` (version, flags, creation time, modification time, time scale, duration)
+			mediaInfo.Timescale, mediaInfo.Duration, err = readMediaHeader(r.f, box)
+		case ".moov.trak":
+			if !box.IsContainable /* start of track */ {
+				track = TrackInfo{}
+				continue
+			}
+			mediaInfo.Tracks = append(mediaInfo.Tracks, track)
+		case ".moov.trak.tkhd":
+			track.Id, err = readTrackId(r.f, box)
+		case ".moov.trak.mdia.hdlr":
+			track.HandlerType, err = readHandlerType(r.f, box)
+		case ".moov.trak.mdia.mdhd":
+			track.Timescale, track.Duration, err = readMediaHeader(r.f, box)
+		case ".moov.trak.mdia.minf.stbl.stsd":
+			buf := &bytes.Buffer{}
+			err = copy(r.f, box.DataPosition, box.DataSize, buf)
+			if err != nil {
+				return MediaInfo{}, err
+			}
+			err = readSampleDescription(buf.Bytes(), &track)
+			if err != nil {
+				err = fmt.Errorf("%s %w", box.Path, err)
+			}
+		case ".moov.trak.mdia.minf.stbl.stsz":
+			var sizes []int64
+			sizes, err = readSampleSizes(r.f, box, math.MaxInt32)
+			track.SampleCount = int64(len(sizes))
+			for _, size := range sizes {
+				track.SampleDataSize += size
+			}
+		}
+		if err != nil {
+			return MediaInfo{}, err
+		}
+	}
+	return mediaInfo, nil
+}
+
+// readSampleDescription reads the first sample description of `stsd` box.
+// https://developer.apple.com/documentation/quicktime-file-format/sample_description_atom
+func readSampleDescription(data []byte, track *TrackInfo) error {
+	if len(data) < 8 /* version, flags, entry count */ {
+		return ErrInvalidSampleDescription
+	}
+	entryCount, err := binary.BigEdian.ReadI32(bytes.NewReader(data[4:8]))
+	if err != nil {
+		return err
+	}
+	if entryCount == 0 {
+		return nil
+	}
+	entry := data[8:]
+	if len(entry) < 16 /* size, format, reserved, data reference index */ {
+		return ErrInvalidSampleDescription
+	}
+	entrySize32, err := binary.BigEdian.ReadI32(bytes.NewReader(entry[:4]))
+	if err != nil {
+		return err
+	}
+	entrySize := int(uint32(entrySize32))
+	if entrySize < 16 || len(entry) < entrySize {
+		return ErrInvalidSampleDescription
+	}
+	track.Format = string(entry[4:8])
+	entry = entry[16:entrySize]
+
+	switch track.HandlerType {
+	case "soun":
+		return readSoundDescription(entry, track)
+	case "vide":
+		return readVideoDescription(entry, track)
+	}
+	return nil
+}
+
+// readSoundDescription reads the sound sample description (after the data reference index).
+// https://developer.apple.com/documentation/quicktime-file-format/sound_sample_descriptions
+func readSoundDescription(data []byte, track *TrackInfo) error {
+	if len(data) < 20 {
+		return ErrInvalidSampleDescription
+	}
+	version, err := binary.BigEdian.ReadI16(bytes.NewReader(data[:2]))
+	if err != nil {
+		return err
+	}
+	childrenOffset := 20
+
+	codecs := map[string]string{"alac": "ALAC", "ac-3": "AC-3", "ec-3": "E-AC-3", "Opus": "Opus", "fLaC": "FLAC", "lpcm": "LPCM", "sowt": "LPCM", "twos": "LPCM"}
+	track.Codec = codecs[track.Format]
+
+	switch version {
+	case 0, 1:
+		r := bytes.NewReader(data[8:])
+		channels, err := binary.BigEdian.ReadI16(r)
+		if err != nil {
+			return err
+		}
+		bitDepth, err := binary.BigEdian.ReadI16(r)
+		if err != nil {
+			return err
+		}
+		_, err = r.Seek(4 /* compression ID, packet size */, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		sampleRate, err := binary.BigEdian.ReadI32(r)
+		if err != nil {
+			return err
+		}
+		track.Channels = int(uint16(channels))
+		track.BitDepth = int(uint16(bitDepth))
+		track.SampleRate = int64(uint32(sampleRate) >> 16 /* 16.16 fixed-point */)
+		if version == 1 {
+			childrenOffset += 16 /* samples per packet, bytes per packet, bytes per frame, bytes per sample */
+		}
+	case 2:
+		if len(data) < 56 {
+			return ErrInvalidSampleDescription
+		}
+		r := bytes.NewReader(data[24:])
+		sampleRate, err := binary.BigEdian.ReadI64(r)
+		if err != nil {
+			return err
+		}
+		channels, err := binary.BigEdian.ReadI32(r)
+		if err != nil {
+			return err
+		}
+		_, err = r.Seek(4 /* always 0x7F000000 */, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		bitDepth, err := binary.BigEdian.ReadI32(r)
+		if err != nil {
+			return err
+		}
+		track.SampleRate = int64(math.Float64frombits(uint64(sampleRate)))
+		track.Channels = int(uint32(channels))
+		track.BitDepth = int(uint32(bitDepth))
+		childrenOffset = 56
+	default:
+		// format is known, but the layout of the fields is not
+		slog.Debug(fmt.Sprintf("unsupported sound sample description version (%d)", version))
+		return nil
+	}
+
+	for name, child := range childBoxes(data[childrenOffset:]) {
+		switch name {
+		case "esds":
+			readElementaryStreamDescriptor(child, track)
+		case "wave":
+			// QuickTime sound sample description version 1 has `esds` in `wave` box
+			for name, child := range childBoxes(child) {
+				if name == "esds" {
+					readElementaryStreamDescriptor(child, track)
+				}
+			}
+		case "alac":
+			// https://github.com/macosforge/alac/blob/master/ALACMagicCookieDescription.txt
+			if len(child) < 4+24 {
+				continue
+			}
+			config := child[4:] // after version, flags
+			sampleRate, err := binary.BigEdian.ReadI32(bytes.NewReader(config[20:24]))
+			if err != nil {
+				continue
+			}
+			track.BitDepth = int(config[5])
+			track.Channels = int(config[9])
+			track.SampleRate = int64(uint32(sampleRate))
+		case "dac3":
+			// ETSI TS 102 366 Annex F
+			if len(child) < 3 {
+				continue
+			}
+			readAC3SpecificBox(child, track)
+		}
+	}
+	return nil
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/video_sample_description
+func readVideoDescription(data []byte, track *TrackInfo) error {
+	if len(data) < 20 {
+		return ErrInvalidSampleDescription
+	}
+	r := bytes.NewReader(data[16:20])
+	width, err := binary.BigEdian.ReadI16(r)
+	if err != nil {
+		return err
+	}
+	height, err := binary.BigEdian.ReadI16(r)
+	if err != nil {
+		return err
+	}
+	track.Width = int(uint16(width))
+	track.Height = int(uint16(height))
+	codecs := map[string]string{"avc1": "H.264", "avc3": "H.264", "hvc1": "H.265", "hev1": "H.265", "mp4v": "MPEG-4 Visual", "av01": "AV1", "vp09": "VP9", "apcn": "ProRes", "jpeg": "Motion JPEG"}
+	track.Codec = codecs[track.Format]
+	return nil
+}
+
+// readElementaryStreamDescriptor reads the codec, sample rate and channels of `esds` box (ISO/IEC 14496-1).
+func readElementaryStreamDescriptor(data []byte, track *TrackInfo) {
+	descriptors := data[min(4 /* version, flags */, len(data)):]
+	for len(descriptors) > 0 {
+		tag, size, headerSize := readDescriptorHeader(descriptors)
+		if headerSize == 0 || len(descriptors) < headerSize+size {
+			return
+		}
+		body := descriptors[headerSize : headerSize+size]
+		switch tag {
+		case 0x03: // ES_Descriptor
+			if len(body) < 3 {
+				return
+			}
+			flags, offset := body[2], 3 /* ES_ID, flags */
+			if flags&0x80 != 0 {
+				offset += 2 /* dependsOn_ES_ID */
+			}
+			if flags&0x40 != 0 && offset < len(body) {
+				offset += 1 + int(body[offset]) /* URL */
+			}
+			if flags&0x20 != 0 {
+				offset += 2 /* OCR_ES_Id */
+			}
+			descriptors = body[min(offset, len(body)):]
+			continue
+		case 0x04: // DecoderConfigDescriptor
+			if len(body) < 13 {
+				return
+			}
+			switch body[0] /* objectTypeIndication */ {
+			case 0x40, 0x66, 0x67, 0x68:
+				track.Codec = "AAC"
+			case 0x69, 0x6B:
+				track.Codec = "MP3"
+			}
+			descriptors = body[13:]
+			continue
+		case 0x05: // DecoderSpecificInfo
+			if track.Codec == "AAC" {
+				readAudioSpecificConfig(body, track)
+			}
+		}
+		descriptors = descriptors[headerSize+size:]
+	}
+}
+
+// readDescriptorHeader reads the tag and the size (up to 4 bytes, 7 bit per byte) of the descriptor.
+func readDescriptorHeader(data []byte) (tag byte, size, headerSize int) {
+	if len(data) < 2 {
+		return 0, 0, 0
+	}
+	for i := 1; i < min(5, len(data)); i++ {
+		size = size<<7 | int(data[i]&0x7F)
+		if data[i]&0x80 == 0 {
+			return data[0], size, i + 1
+		}
+	}
+	return 0, 0, 0
+}
+
+// readAudioSpecificConfig reads the audio object type, sample rate and channels of AudioSpecificConfig (ISO/IEC 14496-3).
+func readAudioSpecificConfig(data []byte, track *TrackInfo) {
+	bits := &bitReader{data: data}
+	audioObjectType := bits.read(5)
+	if audioObjectType == 31 {
+		audioObjectType = 32 + bits.read(6)
+	}
+	sampleRates := []int64{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+	sampleRateIndex := bits.read(4)
+	switch {
+	case sampleRateIndex == 0xF:
+		track.SampleRate = int64(bits.read(24))
+	case int(sampleRateIndex) < len(sampleRates):
+		track.SampleRate = sampleRates[sampleRateIndex]
+	}
+	if channelConfiguration := bits.read(4); 0 < channelConfiguration && channelConfiguration < 7 {
+		track.Channels = int(channelConfiguration)
+	} else if channelConfiguration == 7 {
+		track.Channels = 8
+	}
+	if bits.overrun {
+		return
+	}
+
+	names := map[uint32]string{1: "AAC Main", 2: "AAC LC", 3: "AAC SSR", 4: "AAC LTP", 5: "HE-AAC", 29: "HE-AACv2", 23: "AAC LD", 39: "AAC ELD"}
+	if name, ok := names[audioObjectType]; ok {
+		track.Codec = name
+	}
+}
+
+// readAC3SpecificBox reads the sample rate and channels of `dac3` box.
+func readAC3SpecificBox(data []byte, track *TrackInfo) {
+	bits := &bitReader{data: data}
+	fscod := bits.read(2)
+	bits.read(5 /* bsid */ + 3 /* bsmod */)
+	acmod := bits.read(3)
+	lfeon := bits.read(1)
+	track.SampleRate = []int64{48000, 44100, 32000, 0}[fscod]
+	track.Channels = []int{2, 1, 2, 3, 3, 4, 4, 5}[acmod] + int(lfeon)
+}
+
+// childBoxes iterates the boxes in data.
+func childBoxes(data []byte) iter.Seq2[string, []byte] {
+	return func(yield func(name string, data []byte) bool) {
+		for offset := 0; offset+8 <= len(data); {
+			size32, err := binary.BigEdian.ReadI32(bytes.NewReader(data[offset : offset+4]))
+			if err != nil {
+				return
+			}
+			size := int(uint32(size32))
+			if size < 8 || len(data) < offset+size {
+				return
+			}
+			if !yield(string(data[offset+4:offset+8]), data[offset+8:offset+size]) {
+				return
+			}
+			offset += size
+		}
+	}
+}
+
+// bitReader reads big-endian bits. Bits after the end of data are read as zero.
+type bitReader struct {
+	data    []byte
+	offset  int
+	overrun bool
+}
+
+func (b *bitReader) read(n int) uint32 {
+	value := uint32(0)
+	for range n {
+		bit := uint32(0)
+		if b.offset/8 < len(b.data) {
+			bit = uint32(b.data[b.offset/8]>>(7-b.offset%8)) & 1
+		} else {
+			b.overrun = true
+		}
+		value = value<<1 | bit
+		b.offset++
+	}
+	return value
+}
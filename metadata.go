@@ -0,0 +1,298 @@
+package qtffilst
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+	"github.com/tingtt/qtffilst/mdta"
+)
+
+var (
+	ErrMetadataHandlerMismatch  = errors.New(".moov.meta is not the metadata with keys (mdta)")
+	ErrMetadataKeysDoesNotExist = errors.New(".moov.meta.keys or .moov.meta.ilst does not exists")
+	ErrTrackDoesNotExist        = errors.New(".moov.trak does not exists")
+)
+
+// ReadMetadata reads the metadata with keys (`.moov.meta.keys` and `.moov.meta.ilst`).
+// Returns empty Metadata if `.moov.meta` does not exist.
+func (r *reader) ReadMetadata() (mdta.Metadata, error) {
+	layout, err := readMetadataLayout(r.f, r.size)
+	if err != nil {
+		return nil, err
+	}
+	if layout.exists && layout.handlerType != mdta.HandlerType {
+		return mdta.Metadata{}, nil
+	}
+	return layout.metadata, nil
+}
+
+type metadataLayout struct {
+	exists      bool
+	handlerType string
+	keysExists  bool
+	ilstExists  bool
+	trakCount   int
+	// Keys in the order of `.moov.meta.keys`
+	keys []mdta.Key
+	// Values by the key index (including the keys of the namespace other than mdta.KeyNamespace)
+	values   map[int][]mdta.Value
+	metadata mdta.Metadata
+}
+
+func readMetadataLayout(rs io.ReadSeeker, size int64) (metadataLayout, error) {
+	layout := metadataLayout{values: map[int][]mdta.Value{}, metadata: mdta.Metadata{}}
+
+	for box, err := range Walk(rs, size) {
+		if err != nil {
+			return metadataLayout{}, err
+		}
+
+		switch {
+		case box.Path == ".moov.trak" && box.IsContainable:
+			layout.trakCount++
+		case box.Path == ".moov.meta":
+			layout.exists = true
+		case box.Path == ".moov.meta.ilst":
+			layout.ilstExists = true
+		case box.Path == ".moov.meta.hdlr":
+			// https://developer.apple.com/documentation/quicktime-file-format/handler_reference_atom
+			_, err = rs.Seek(box.DataPosition+8 /* version, flags, component type */, io.SeekStart)
+			if err != nil {
+				return metadataLayout{}, err
+			}
+			buf, err := binary.Read(rs, 4)
+			if err != nil {
+				return metadataLayout{}, err
+			}
+			layout.handlerType = string(buf)
+		case box.Path == ".moov.meta.keys":
+			buf := &bytes.Buffer{}
+			err = copy(rs, box.DataPosition, box.DataSize, buf)
+			if err != nil {
+				return metadataLayout{}, err
+			}
+			layout.keys, err = mdta.DecodeKeys(buf.Bytes())
+			if err != nil {
+				return metadataLayout{}, err
+			}
+			layout.keysExists = true
+		case mdtaDataBox(box):
+			index, err := mdta.KeyIndex(strings.TrimPrefix(box.Path, ".moov.meta.ilst.")[:4])
+			if err != nil {
+				return metadataLayout{}, err
+			}
+			buf := &bytes.Buffer{}
+			err = copy(rs, box.DataPosition, box.DataSize, buf)
+			if err != nil {
+				return metadataLayout{}, err
+			}
+			value, err := mdta.DecodeValue(buf.Bytes())
+			if err != nil {
+				return metadataLayout{}, fmt.Errorf("%w (key index: %d)", err, index)
+			}
+			layout.values[index] = append(layout.values[index], value)
+		}
+	}
+
+	for index, values := range layout.values {
+		if index >= len(layout.keys) {
+			slog.Debug(fmt.Sprintf("box: .moov.meta.ilst item of undefined key index (%d)", index+1))
+			continue
+		}
+		key := layout.keys[index]
+		if key.Namespace != mdta.KeyNamespace {
+			slog.Debug(fmt.Sprintf("box: .moov.meta.ilst item of unsupported key namespace (%s: %s)", key.Namespace, key.Name))
+			continue
+		}
+		layout.metadata[key.Name] = values
+	}
+	return layout, nil
+}
+
+// WriteMetadata writes the metadata with keys (`.moov.meta.keys` and `.moov.meta.ilst`).
+// Keys are reindexed, and `.moov.meta` is created if it does not exist.
+func (r *readWriter) WriteMetadata(dest, tmpDest *os.File, metadata mdta.Metadata, deleteKeys []string) error {
+	layout, err := readMetadataLayout(r.f, r.size)
+	if err != nil {
+		return err
+	}
+	if layout.exists && layout.handlerType != mdta.HandlerType {
+		return ErrMetadataHandlerMismatch
+	}
+	if layout.exists && (!layout.keysExists || !layout.ilstExists) {
+		return ErrMetadataKeysDoesNotExist
+	}
+
+	if len(metadata) == 0 && len(deleteKeys) == 0 {
+		_, err := r.f.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dest, r.f)
+		return err
+	}
+
+	// Keys of the other namespaces are kept with their values
+	type entry struct {
+		key    mdta.Key
+		values []mdta.Value
+	}
+	entries := []entry{}
+	for index, key := range layout.keys {
+		if key.Namespace == mdta.KeyNamespace && slices.Contains(deleteKeys, key.Name) {
+			slog.Info("remove", slog.String("key", key.Name))
+			continue
+		}
+		entries = append(entries, entry{key, layout.values[index]})
+	}
+	for _, name := range slices.Sorted(maps.Keys(metadata)) {
+		index := slices.IndexFunc(entries, func(e entry) bool { return e.key == mdta.Key{Namespace: mdta.KeyNamespace, Name: name} })
+		if index != -1 {
+			entries[index].values = metadata[name]
+			slog.Info("modify", slog.String("key", name))
+			continue
+		}
+		entries = append(entries, entry{mdta.Key{Namespace: mdta.KeyNamespace, Name: name}, metadata[name]})
+		slog.Info("append", slog.String("key", name))
+	}
+
+	keys := []mdta.Key{}
+	for _, e := range entries {
+		keys = append(keys, e.key)
+	}
+	keysData := mdta.EncodeKeys(keys)
+	ilstData := &bytes.Buffer{}
+	for i, e := range entries {
+		values := e.values
+		if len(values) == 0 {
+			continue
+		}
+		itemData := &bytes.Buffer{}
+		for _, value := range values {
+			err = writeBox(itemData, "data", value.Bytes())
+			if err != nil {
+				return err
+			}
+		}
+		err = writeBox(ilstData, mdta.ItemName(i), itemData.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Modify `.moov.meta.keys` and `.moov.meta.ilst`, or append `.moov.meta`
+	if !layout.exists && layout.trakCount == 0 {
+		return ErrTrackDoesNotExist
+	}
+	fileType, err := readFileType(r.f, r.size)
+	if err != nil {
+		return err
+	}
+	trakCount := 0
+	for box, err := range WritableWalk(r.f, r.size, tmpDest) {
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case box.Path == ".moov.meta.keys":
+			_, err = box.Write(keysData)
+		case box.Path == ".moov.meta.ilst" && box.IsContainable:
+			_, err = box.Write(ilstData.Bytes())
+		case box.Path == ".moov.trak" && box.IsContainable:
+			trakCount++
+			if layout.exists || trakCount != layout.trakCount {
+				continue
+			}
+			// append `.moov.meta` after the last track
+			// (full box in ISO base media files, plain box in QuickTime movie files)
+			metaData := &bytes.Buffer{}
+			if !fileType.QuickTime() {
+				metaData.Write(bytes.Repeat([]byte{0x0}, 4) /* version, flags */)
+			}
+			err = writeBox(metaData, "hdlr", mdtaHandlerData())
+			if err != nil {
+				return err
+			}
+			err = writeBox(metaData, "keys", keysData)
+			if err != nil {
+				return err
+			}
+			err = writeBox(metaData, "ilst", ilstData.Bytes())
+			if err != nil {
+				return err
+			}
+			_, err = box.InsertNewBox("meta", metaData.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.copyWithOffsetsPatch(tmpDest, dest)
+}
+
+// copyWithOffsetsPatch copies tmpDest (written from r) to dest.
+// If `free` box follows `moov`, it is resized to absorb the size change of `moov` and no boxes are moved,
+// otherwise the offsets to the moved boxes are patched (see copyWithMovedOffsetsPatch).
+func (r *readWriter) copyWithOffsetsPatch(tmpDest, dest *os.File) error {
+	stat, err := tmpDest.Stat()
+	if err != nil {
+		return err
+	}
+
+	resize, absorbed, err := absorbMoovResize(Walk(r.f, r.size), Walk(tmpDest, stat.Size()))
+	if err != nil {
+		return err
+	}
+	if absorbed && resize.newSize != resize.oldSize {
+		slog.Info("modify", slog.String("id", "free"), slog.String("diff", fmt.Sprintf("%+d", resize.newSize-resize.oldSize)))
+		return copyWithPaddingResized(tmpDest, stat.Size(), dest, resize)
+	}
+	return r.copyWithMovedOffsetsPatch(tmpDest, dest)
+}
+
+// copyWithMovedOffsetsPatch copies tmpDest (written from r) to dest,
+// and patches the offsets to the boxes moved by the modification (e.g. `mdat`, `moof`).
+func (r *readWriter) copyWithMovedOffsetsPatch(tmpDest, dest *os.File) error {
+	stat, err := tmpDest.Stat()
+	if err != nil {
+		return err
+	}
+
+	moves, err := rootBoxMoves(Walk(r.f, r.size), Walk(tmpDest, stat.Size()))
+	if err != nil {
+		return err
+	}
+	if !moved(moves) {
+		slog.Debug("skip modification of offsets because no boxes are moved")
+		_, err := tmpDest.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dest, tmpDest)
+		return err
+	}
+
+	slog.Info("modify offsets", slog.Bool("fragmented", fragmented(moves)))
+	return patchOffsets(tmpDest, stat.Size(), dest, moves)
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/metadata_handler_atom
+func mdtaHandlerData() []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(bytes.Repeat([]byte{0x0}, 4) /* version, flags */)
+	buf.Write(bytes.Repeat([]byte{0x0}, 4) /* predefined */)
+	buf.Write([]byte(mdta.HandlerType))
+	buf.Write(bytes.Repeat([]byte{0x0}, 12) /* reserved */)
+	buf.Write([]byte{0x0} /* name */)
+	return buf.Bytes()
+}
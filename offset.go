@@ -0,0 +1,282 @@
+package qtffilst
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"os"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var (
+	ErrUnpatchableOffset  = errors.New("offset cannot be patched")
+	ErrInvalidOffsetTable = errors.New("invalid offset table")
+)
+
+// boxMove is the position difference of a root level box that is not modified on writing (e.g. `mdat`, `moof`).
+type boxMove struct {
+	name        string
+	oldPosition int64
+	newPosition int64
+	// Size including the header
+	size int64
+}
+
+// rootBoxMoves matches the root level boxes of old and new by name and order,
+// and returns the moves of the boxes whose size is not changed.
+func rootBoxMoves(old, new iter.Seq2[Box, error]) ([]boxMove, error) {
+	oldBoxes, err := rootBoxes(old)
+	if err != nil {
+		return nil, err
+	}
+	newBoxes, err := rootBoxes(new)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := []boxMove{}
+	for name, boxes := range oldBoxes {
+		for i, oldBox := range boxes {
+			if i >= len(newBoxes[name]) || newBoxes[name][i].DataSize != oldBox.DataSize {
+				continue
+			}
+			moves = append(moves, boxMove{
+				name:        name,
+				oldPosition: oldBox.DataPosition - 8,            /* size, name */
+				newPosition: newBoxes[name][i].DataPosition - 8, /* size, name */
+				size:        8 /* size, name */ + int64(oldBox.DataSize),
+			})
+		}
+	}
+	return moves, nil
+}
+
+func rootBoxes(seq iter.Seq2[Box, error]) (map[string][]Box, error) {
+	boxes := map[string][]Box{}
+	for box, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		if box.Level == ROOT_LEVEL && !box.IsContainable {
+			boxes[box.Name] = append(boxes[box.Name], box)
+		}
+	}
+	return boxes, nil
+}
+
+// moved reports whether any box is moved.
+func moved(moves []boxMove) bool {
+	for _, move := range moves {
+		if move.newPosition != move.oldPosition {
+			return true
+		}
+	}
+	return false
+}
+
+// newOffset returns the new absolute offset of old one.
+// Offset is moved with the nearest box that starts at or before it (e.g. the end of `mdat` moves with `mdat`),
+// and is not moved if no boxes precede it.
+func newOffset(moves []boxMove, offset int64) int64 {
+	var preceding *boxMove
+	for i, move := range moves {
+		if move.oldPosition <= offset && (preceding == nil || preceding.oldPosition < move.oldPosition) {
+			preceding = &moves[i]
+		}
+	}
+	if preceding == nil {
+		return offset
+	}
+	return offset + preceding.newPosition - preceding.oldPosition
+}
+
+// patchOffsets copies src to dest with the absolute offsets to the moved boxes patched:
+// chunk offsets (`stco`, `co64`), base data offsets of track fragments (`.moof.traf.tfhd`),
+// offsets of movie fragments (`.mfra.tfra`) and the first offset of segment index (`.sidx`).
+// Other offsets of fragmented files (e.g. data offset of `trun`) are relative to `moof`.
+func patchOffsets(src *os.File, size int64, dest io.Writer, moves []boxMove) error {
+	for box, err := range WritableWalk(src, size, dest) {
+		if err != nil {
+			return err
+		}
+
+		var patch func(data []byte, moves []boxMove) error
+		switch box.Path {
+		case ".moov.trak.mdia.minf.stbl.stco":
+			patch = patchChunkOffsets(4)
+		case ".moov.trak.mdia.minf.stbl.co64":
+			patch = patchChunkOffsets(8)
+		case ".moof.traf.tfhd":
+			patch = patchBaseDataOffset
+		case ".mfra.tfra":
+			patch = patchMovieFragmentOffsets
+		case ".sidx":
+			patch = patchSegmentIndexFirstOffset(box.DataPosition + int64(box.DataSize))
+		default:
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		err = copy(src, box.DataPosition, box.DataSize, buf)
+		if err != nil {
+			return err
+		}
+		data := buf.Bytes()
+		err = patch(data, moves)
+		if err != nil {
+			return fmt.Errorf("%w (%s)", err, box.Path)
+		}
+		slog.Debug("modify offsets", slog.String("path", box.Path))
+		_, err = box.Write(data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchChunkOffsets patches the data of `stco` (size 4) or `co64` (size 8).
+// https://developer.apple.com/documentation/quicktime-file-format/chunk_offset_atom
+// https://developer.apple.com/documentation/quicktime-file-format/64-bit_chunk_offset_atom
+func patchChunkOffsets(size int) func(data []byte, moves []boxMove) error {
+	return func(data []byte, moves []boxMove) error {
+		if len(data) < 8 {
+			return ErrInvalidOffsetTable
+		}
+		entryCount := readEntryCount(data[4:8])
+		if len(data) < 8+entryCount*size {
+			return ErrInvalidOffsetTable
+		}
+		for i := range entryCount {
+			err := patchOffset(data[8+i*size:8+(i+1)*size], moves)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// patchBaseDataOffset patches the data of `tfhd`.
+// Data is "<version (8 bit)><flags (24 bit)><track ID (32 bit)>[<base data offset (64 bit)> if flags & 0x1]..."
+func patchBaseDataOffset(data []byte, moves []boxMove) error {
+	if len(data) < 8 {
+		return ErrInvalidOffsetTable
+	}
+	if /* base-data-offset-present */ data[3]&0x1 == 0 {
+		return nil
+	}
+	if len(data) < 16 {
+		return ErrInvalidOffsetTable
+	}
+	return patchOffset(data[8:16], moves)
+}
+
+// patchMovieFragmentOffsets patches the data of `tfra`.
+// Data is "<version (8 bit)><flags (24 bit)><track ID (32 bit)><reserved (26 bit)>
+// <size of traf number, trun number, sample number (2 bit each)><number of entries (32 bit)>"
+// followed by "<time><moof offset><traf number><trun number><sample number>" per entry,
+// and time and moof offset are 64 bit if version 1, otherwise 32 bit.
+func patchMovieFragmentOffsets(data []byte, moves []boxMove) error {
+	if len(data) < 16 {
+		return ErrInvalidOffsetTable
+	}
+	size := 4
+	if data[0] == 1 {
+		size = 8
+	}
+	sizes := data[11]
+	entrySize := size*2 + int(sizes>>4&0x3+1) + int(sizes>>2&0x3+1) + int(sizes&0x3+1)
+	entryCount := readEntryCount(data[12:16])
+	if len(data) < 16+entryCount*entrySize {
+		return ErrInvalidOffsetTable
+	}
+	for i := range entryCount {
+		offset := 16 + i*entrySize + size /* time */
+		err := patchOffset(data[offset:offset+size], moves)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchSegmentIndexFirstOffset patches the data of `sidx` that ends at endsAt.
+// Data is "<version (8 bit)><flags (24 bit)><reference ID (32 bit)><timescale (32 bit)>
+// <earliest presentation time><first offset>...", and they are 64 bit if version 1, otherwise 32 bit.
+// First offset is relative to the end of `sidx`, so it changes only if `sidx` and the referenced box move differently.
+func patchSegmentIndexFirstOffset(endsAt int64) func(data []byte, moves []boxMove) error {
+	return func(data []byte, moves []boxMove) error {
+		size := 4
+		if len(data) > 0 && data[0] == 1 {
+			size = 8
+		}
+		if len(data) < 12+size*2 {
+			return ErrInvalidOffsetTable
+		}
+		field := data[12+size : 12+size*2]
+
+		oldEndsAt := int64(-1)
+		for _, move := range moves {
+			if move.name == "sidx" && move.newPosition+move.size == endsAt {
+				oldEndsAt = move.oldPosition + move.size
+			}
+		}
+		if oldEndsAt == -1 {
+			return ErrUnpatchableOffset
+		}
+		target := newOffset(moves, oldEndsAt+readOffset(field))
+		writeOffset(field, target-endsAt)
+		return nil
+	}
+}
+
+// patchOffset patches the absolute offset (32 or 64 bit) in field.
+func patchOffset(field []byte, moves []boxMove) error {
+	offset := newOffset(moves, readOffset(field))
+	if len(field) == 4 && offset > 0xFFFFFFFF {
+		return fmt.Errorf("%w (offset %d exceeds 32 bit)", ErrUnpatchableOffset, offset)
+	}
+	writeOffset(field, offset)
+	return nil
+}
+
+// readEntryCount reads the 32 bit unsigned entry count.
+func readEntryCount(field []byte) int {
+	return int(readOffset(field[:4]))
+}
+
+// readOffset reads the 32 or 64 bit unsigned offset of field (length is checked by the caller).
+func readOffset(field []byte) int64 {
+	if len(field) == 4 {
+		offset, _ := binary.BigEdian.ReadI32(bytes.NewReader(field))
+		return int64(uint32(offset))
+	}
+	offset, _ := binary.BigEdian.ReadI64(bytes.NewReader(field))
+	return offset
+}
+
+// writeOffset writes offset to field in place.
+func writeOffset(field []byte, offset int64) {
+	buf := binary.BigEdian.BytesI64(offset)
+	if len(field) == 4 {
+		buf = binary.BigEdian.BytesI32(int32(offset))
+	}
+	for i := range field {
+		field[i] = buf[i]
+	}
+}
+
+// fragmented reports whether the file has movie fragments (`moof`).
+func fragmented(moves []boxMove) bool {
+	for _, move := range moves {
+		if move.name == "moof" {
+			return true
+		}
+	}
+	return false
+}
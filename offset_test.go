@@ -0,0 +1,171 @@
+package qtffilst
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var testMoves = []boxMove{
+	{name: "moov", oldPosition: 100, newPosition: 100, size: 400},
+	{name: "mdat", oldPosition: 1000, newPosition: 1100, size: 500},
+	{name: "moof", oldPosition: 2000, newPosition: 2100, size: 100},
+}
+
+func u32(v int64) []byte { return binary.BigEdian.BytesI32(int32(v)) }
+func u64(v int64) []byte { return binary.BigEdian.BytesI64(v) }
+
+func TestNewOffset(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int64
+		want   int64
+	}{
+		{"before all boxes", 0, 0},
+		{"in unmoved box", 120, 120},
+		{"start of moved box", 1000, 1100},
+		{"in moved box", 1200, 1300},
+		{"end of moved box", 1500, 1600},
+		{"after last box", 5000, 5100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newOffset(testMoves, tt.offset); got != tt.want {
+				t.Errorf("newOffset(%d) = %d, want %d", tt.offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchOffsets(t *testing.T) {
+	tfraSizes := func(traf, trun, sample byte) []byte { return []byte{0, 0, 0, traf<<4 | trun<<2 | sample} }
+
+	tests := []struct {
+		name    string
+		patch   func(data []byte, moves []boxMove) error
+		data    []byte
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:  "stco",
+			patch: patchChunkOffsets(4),
+			data:  slices.Concat(u32(0), u32(3), u32(1000), u32(1200), u32(0)),
+			want:  slices.Concat(u32(0), u32(3), u32(1100), u32(1300), u32(0)),
+		},
+		{
+			name:    "stco entry count exceeds data",
+			patch:   patchChunkOffsets(4),
+			data:    slices.Concat(u32(0), u32(3), u32(1000)),
+			wantErr: ErrInvalidOffsetTable,
+		},
+		{
+			name:  "co64",
+			patch: patchChunkOffsets(8),
+			data:  slices.Concat(u32(0), u32(2), u64(1000), u64(1499)),
+			want:  slices.Concat(u32(0), u32(2), u64(1100), u64(1599)),
+		},
+		{
+			name:  "tfhd with base data offset",
+			patch: patchBaseDataOffset,
+			data:  slices.Concat([]byte{0, 0, 0, 0x1}, u32(1), u64(2000), u32(0)),
+			want:  slices.Concat([]byte{0, 0, 0, 0x1}, u32(1), u64(2100), u32(0)),
+		},
+		{
+			name:  "tfhd without base data offset",
+			patch: patchBaseDataOffset,
+			data:  slices.Concat([]byte{0, 0x2, 0, 0x2}, u32(1), u32(1000), u32(2000)),
+			want:  slices.Concat([]byte{0, 0x2, 0, 0x2}, u32(1), u32(1000), u32(2000)),
+		},
+		{
+			name:  "tfra version 0, 1 byte numbers",
+			patch: patchMovieFragmentOffsets,
+			data: slices.Concat([]byte{0, 0, 0, 0}, u32(1), tfraSizes(0, 0, 0), u32(2),
+				u32(0), u32(2000), []byte{1, 1, 1},
+				u32(512), u32(2050), []byte{1, 1, 2}),
+			want: slices.Concat([]byte{0, 0, 0, 0}, u32(1), tfraSizes(0, 0, 0), u32(2),
+				u32(0), u32(2100), []byte{1, 1, 1},
+				u32(512), u32(2150), []byte{1, 1, 2}),
+		},
+		{
+			name:  "tfra version 1, mixed size numbers",
+			patch: patchMovieFragmentOffsets,
+			data: slices.Concat([]byte{1, 0, 0, 0}, u32(1), tfraSizes(1, 2, 3), u32(2),
+				u64(0), u64(2000), []byte{0, 1}, []byte{0, 0, 1}, u32(1),
+				u64(512), u64(2050), []byte{0, 1}, []byte{0, 0, 1}, u32(2)),
+			want: slices.Concat([]byte{1, 0, 0, 0}, u32(1), tfraSizes(1, 2, 3), u32(2),
+				u64(0), u64(2100), []byte{0, 1}, []byte{0, 0, 1}, u32(1),
+				u64(512), u64(2150), []byte{0, 1}, []byte{0, 0, 1}, u32(2)),
+		},
+		{
+			name:    "tfra entry count exceeds data",
+			patch:   patchMovieFragmentOffsets,
+			data:    slices.Concat([]byte{0, 0, 0, 0}, u32(1), tfraSizes(0, 0, 0), u32(2), u32(0), u32(2000), []byte{1, 1, 1}),
+			wantErr: ErrInvalidOffsetTable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := slices.Clone(tt.data)
+			err := tt.patch(data, testMoves)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(data, tt.want) {
+				t.Errorf("data = %x, want %x", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchSegmentIndexFirstOffset(t *testing.T) {
+	// `sidx` (600-650) moves by +20, and `moof` (700) referenced by the first offset moves by +60
+	moves := []boxMove{
+		{name: "sidx", oldPosition: 600, newPosition: 620, size: 50},
+		{name: "moof", oldPosition: 700, newPosition: 760, size: 100},
+	}
+	header := slices.Concat(u32(1) /* reference ID */, u32(44100) /* timescale */)
+	references := slices.Concat(u32(0), u32(1) /* reference count */)
+
+	tests := []struct {
+		name    string
+		endsAt  int64
+		data    []byte
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:   "version 0",
+			endsAt: 670,
+			data:   slices.Concat([]byte{0, 0, 0, 0}, header, u32(0), u32(50), references),
+			want:   slices.Concat([]byte{0, 0, 0, 0}, header, u32(0), u32(90), references),
+		},
+		{
+			name:   "version 1",
+			endsAt: 670,
+			data:   slices.Concat([]byte{1, 0, 0, 0}, header, u64(0), u64(50), references),
+			want:   slices.Concat([]byte{1, 0, 0, 0}, header, u64(0), u64(90), references),
+		},
+		{
+			name:    "sidx not found in moves",
+			endsAt:  999,
+			data:    slices.Concat([]byte{0, 0, 0, 0}, header, u32(0), u32(50), references),
+			wantErr: ErrUnpatchableOffset,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := slices.Clone(tt.data)
+			err := patchSegmentIndexFirstOffset(tt.endsAt)(data, moves)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(data, tt.want) {
+				t.Errorf("data = %x, want %x", data, tt.want)
+			}
+		})
+	}
+}
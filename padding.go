@@ -0,0 +1,86 @@
+package qtffilst
+
+import (
+	"io"
+	"iter"
+	"math"
+	"os"
+)
+
+// paddingResize is the resize of `free` box directly after `moov` that absorbs the size change of `moov`.
+type paddingResize struct {
+	// Position of the `free` box
+	position int64
+	// Sizes including the header (new size is 0 if the box is removed)
+	oldSize int64
+	newSize int64
+}
+
+// absorbMoovResize returns the resize of `free` box directly after `moov` of new
+// that puts the boxes following it back to their positions in old.
+// ok is false if `free` box does not follow `moov` in both old and new,
+// or it is too small to absorb the size change of `moov`.
+func absorbMoovResize(old, new iter.Seq2[Box, error]) (resize paddingResize, ok bool, err error) {
+	oldFree, oldExists, err := freeBoxAfterMoov(old)
+	if err != nil {
+		return paddingResize{}, false, err
+	}
+	newFree, newExists, err := freeBoxAfterMoov(new)
+	if err != nil {
+		return paddingResize{}, false, err
+	}
+	if !oldExists || !newExists {
+		return paddingResize{}, false, nil
+	}
+
+	resize = paddingResize{
+		position: newFree.DataPosition - 8, /* size, name */
+		oldSize:  8 /* size, name */ + int64(newFree.DataSize),
+	}
+	resize.newSize = oldFree.DataPosition + int64(oldFree.DataSize) - resize.position
+	if resize.newSize != 0 && (resize.newSize < 8 /* size, name */ || resize.newSize > math.MaxInt32) {
+		return paddingResize{}, false, nil
+	}
+
+	// following boxes must be put back to their positions
+	matchFree := func(box Box) bool { return box.DataPosition == newFree.DataPosition }
+	moves, err := rootBoxMoves(old, resizedRootBoxes(new, matchFree, int32(resize.newSize-resize.oldSize)))
+	if err != nil {
+		return paddingResize{}, false, err
+	}
+	return resize, !moved(moves), nil
+}
+
+// freeBoxAfterMoov returns `free` box directly after `moov` at the root level.
+func freeBoxAfterMoov(seq iter.Seq2[Box, error]) (free Box, exists bool, err error) {
+	afterMoov := false
+	for box, err := range seq {
+		if err != nil {
+			return Box{}, false, err
+		}
+		if box.Level != ROOT_LEVEL || box.IsContainable {
+			continue
+		}
+		if afterMoov {
+			return box, box.Name == "free", nil
+		}
+		afterMoov = box.Name == "moov"
+	}
+	return Box{}, false, nil
+}
+
+// copyWithPaddingResized copies src to dest with the `free` box resized (or removed if the new size is 0).
+func copyWithPaddingResized(src *os.File, size int64, dest io.Writer, resize paddingResize) error {
+	_, err := io.Copy(dest, io.NewSectionReader(src, 0, resize.position))
+	if err != nil {
+		return err
+	}
+	if resize.newSize != 0 {
+		err = writeBox(dest, "free", make([]byte, resize.newSize-8 /* size, name */))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.Copy(dest, io.NewSectionReader(src, resize.position+resize.oldSize, size-resize.position-resize.oldSize))
+	return err
+}
@@ -0,0 +1,144 @@
+package qtffilst
+
+import (
+	"bytes"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type testRootBox struct {
+	name string
+	// Size including the header
+	size int32
+}
+
+// testRootBoxes iterates boxes put in order from the start of the file, as Walk does.
+// `moov` is followed by its child `free` box, which is not a root level box.
+func testRootBoxes(boxes ...testRootBox) iter.Seq2[Box, error] {
+	return func(yield func(Box, error) bool) {
+		position := int64(0)
+		for _, box := range boxes {
+			if !yield(Box{Name: box.name, Level: ROOT_LEVEL, Path: "." + box.name, DataPosition: position + 8, DataSize: box.size - 8}, nil) {
+				return
+			}
+			if box.name == "moov" && !yield(Box{Name: "free", Level: 1, Path: ".moov.free", DataPosition: position + 16, DataSize: 0}, nil) {
+				return
+			}
+			position += int64(box.size)
+		}
+	}
+}
+
+func TestAbsorbMoovResize(t *testing.T) {
+	old := []testRootBox{{"ftyp", 32}, {"moov", 1000}, {"free", 100}, {"mdat", 5000}}
+	tests := []struct {
+		name       string
+		old        []testRootBox
+		new        []testRootBox
+		want       paddingResize
+		wantAbsorb bool
+	}{
+		{
+			name:       "moov grows",
+			old:        old,
+			new:        []testRootBox{{"ftyp", 32}, {"moov", 1050}, {"free", 100}, {"mdat", 5000}},
+			want:       paddingResize{position: 1082, oldSize: 100, newSize: 50},
+			wantAbsorb: true,
+		},
+		{
+			name:       "moov shrinks",
+			old:        old,
+			new:        []testRootBox{{"ftyp", 32}, {"moov", 800}, {"free", 100}, {"mdat", 5000}},
+			want:       paddingResize{position: 832, oldSize: 100, newSize: 300},
+			wantAbsorb: true,
+		},
+		{
+			name:       "free is left with header only",
+			old:        old,
+			new:        []testRootBox{{"ftyp", 32}, {"moov", 1092}, {"free", 100}, {"mdat", 5000}},
+			want:       paddingResize{position: 1124, oldSize: 100, newSize: 8},
+			wantAbsorb: true,
+		},
+		{
+			name:       "free is removed",
+			old:        old,
+			new:        []testRootBox{{"ftyp", 32}, {"moov", 1100}, {"free", 100}, {"mdat", 5000}},
+			want:       paddingResize{position: 1132, oldSize: 100, newSize: 0},
+			wantAbsorb: true,
+		},
+		{
+			name: "free is smaller than header",
+			old:  old,
+			new:  []testRootBox{{"ftyp", 32}, {"moov", 1095}, {"free", 100}, {"mdat", 5000}},
+		},
+		{
+			name: "free is smaller than size change",
+			old:  old,
+			new:  []testRootBox{{"ftyp", 32}, {"moov", 1150}, {"free", 100}, {"mdat", 5000}},
+		},
+		{
+			name: "no free after moov",
+			old:  []testRootBox{{"ftyp", 32}, {"moov", 1000}, {"mdat", 5000}, {"free", 100}},
+			new:  []testRootBox{{"ftyp", 32}, {"moov", 1050}, {"mdat", 5000}, {"free", 100}},
+		},
+		{
+			name: "free after moov is removed",
+			old:  old,
+			new:  []testRootBox{{"ftyp", 32}, {"moov", 1050}, {"mdat", 5000}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, absorbed, err := absorbMoovResize(testRootBoxes(tt.old...), testRootBoxes(tt.new...))
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if absorbed != tt.wantAbsorb {
+				t.Fatalf("absorbed = %t, want %t", absorbed, tt.wantAbsorb)
+			}
+			if got != tt.want {
+				t.Errorf("resize = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyWithPaddingResized(t *testing.T) {
+	free := func(size int) []byte { return slices.Concat(u32(int64(size)), []byte("free"), make([]byte, size-8)) }
+	src := slices.Concat([]byte("head"), free(16), []byte("tail"))
+
+	tests := []struct {
+		name    string
+		newSize int64
+		want    []byte
+	}{
+		{"shrink", 8, slices.Concat([]byte("head"), free(8), []byte("tail"))},
+		{"grow", 24, slices.Concat([]byte("head"), free(24), []byte("tail"))},
+		{"remove", 0, []byte("headtail")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "src")
+			if err := os.WriteFile(path, src, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			dest := &bytes.Buffer{}
+			err = copyWithPaddingResized(f, int64(len(src)), dest, paddingResize{position: 4, oldSize: 16, newSize: tt.newSize})
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !bytes.Equal(dest.Bytes(), tt.want) {
+				t.Errorf("dest = %q, want %q", dest.Bytes(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package qtffilst
+
+import (
+	"bytes"
+	"iter"
+	"maps"
+
+	"github.com/tingtt/qtffilst/ilst"
+)
+
+type ChangeKind string
+
+const (
+	ChangeKindModify ChangeKind = "modify"
+	ChangeKindRemove ChangeKind = "remove"
+	ChangeKindAppend ChangeKind = "append"
+)
+
+type Change struct {
+	Id   string
+	Kind ChangeKind
+	Old  any // nil if ChangeKindAppend
+	New  any // nil if ChangeKindRemove
+	// Size difference of the item box (bytes)
+	SizeDiff int32
+}
+
+type Plan struct {
+	Changes []Change
+	// Size difference of `.moov.udta.meta.ilst` (bytes)
+	IlstSizeDiff int32
+	// Offsets to the moved boxes (e.g. chunk offsets `.moov.trak.mdia.minf.stbl.stco`) will be patched
+	PatchChunkOffsets bool
+}
+
+// Plan computes the changes that Write will make, without writing.
+func (r *readWriter) Plan(newItemList ilst.ItemList, deleteIds []string) (Plan, error) {
+	modifyItemIds := maps.Collect(ilst.Values(&newItemList))
+	for _, deleteId := range deleteIds {
+		modifyItemIds[deleteId] = nil
+	}
+
+	oldItemList := ilst.ItemList{}
+	oldEncodedValues := map[string][]byte{}
+	oldItemSizes := map[string]int32{}
+	for box, err := range WalkSupportedBox(r.f, r.size) {
+		if err != nil {
+			return Plan{}, err
+		}
+
+		ilstBoxName, err := ilstItemId(r.f, box)
+		if err != nil {
+			return Plan{}, err
+		}
+		if !supportedIlstItem(ilstBoxName) {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		err = copy(r.f, box.DataPosition, box.DataSize, buf)
+		if err != nil {
+			return Plan{}, err
+		}
+
+		err = oldItemList.SetDecoded(ilstBoxName, buf.Bytes())
+		if err != nil {
+			return Plan{}, err
+		}
+		oldEncodedValues[ilstBoxName] = append(oldEncodedValues[ilstBoxName], buf.Bytes()...)
+		if _, exists := oldItemSizes[ilstBoxName]; !exists {
+			oldItemSizes[ilstBoxName] = ilstItemHeaderSize(ilstBoxName)
+		}
+		oldItemSizes[ilstBoxName] += box.DataSize + 8 /* data box header */
+	}
+
+	newEncodedValues := map[string][]byte{}
+	newItemSizes := map[string]int32{}
+	for value, err := range ilst.EncodedValues(&newItemList) {
+		if err != nil {
+			return Plan{}, err
+		}
+		newEncodedValues[value.Id] = append(newEncodedValues[value.Id], value.Bytes...)
+		if _, exists := newItemSizes[value.Id]; !exists {
+			newItemSizes[value.Id] = ilstItemHeaderSize(value.Id)
+		}
+		newItemSizes[value.Id] += int32(len(value.Bytes)) + 8 /* data box header */
+	}
+
+	oldValues := maps.Collect(ilst.Values(&oldItemList))
+	plan := Plan{}
+	for id := range ilst.Ids() {
+		newValue, modify := modifyItemIds[id]
+		if !modify {
+			continue
+		}
+		oldValue, exists := oldValues[id]
+
+		var change Change
+		switch {
+		case newValue == nil && exists:
+			change = Change{id, ChangeKindRemove, oldValue, nil, -oldItemSizes[id]}
+		case newValue == nil:
+			continue
+		case exists:
+			if bytes.Equal(oldEncodedValues[id], newEncodedValues[id]) {
+				continue
+			}
+			change = Change{id, ChangeKindModify, oldValue, newValue, newItemSizes[id] - oldItemSizes[id]}
+		default:
+			change = Change{id, ChangeKindAppend, nil, newValue, newItemSizes[id]}
+		}
+		plan.Changes = append(plan.Changes, change)
+		plan.IlstSizeDiff += change.SizeDiff
+	}
+
+	if plan.IlstSizeDiff != 0 {
+		// same as copyWithOffsetsPatch on Write, with `moov` resized by the size difference of ilst
+		matchMoov := func(box Box) bool { return box.Name == "moov" }
+		written := resizedRootBoxes(Walk(r.f, r.size), matchMoov, plan.IlstSizeDiff)
+		moves, err := rootBoxMoves(Walk(r.f, r.size), written)
+		if err != nil {
+			return Plan{}, err
+		}
+		_, absorbed, err := absorbMoovResize(Walk(r.f, r.size), written)
+		if err != nil {
+			return Plan{}, err
+		}
+		plan.PatchChunkOffsets = moved(moves) && !absorbed
+	}
+
+	return plan, nil
+}
+
+// resizedRootBoxes iterates the root level boxes of seq with the first matched box resized by diff,
+// and the following boxes moved by diff.
+func resizedRootBoxes(seq iter.Seq2[Box, error], match func(box Box) bool, diff int32) iter.Seq2[Box, error] {
+	return func(yield func(Box, error) bool) {
+		resized, shift := false, int64(0)
+		for box, err := range seq {
+			if err != nil {
+				yield(Box{}, err)
+				return
+			}
+			if box.Level != ROOT_LEVEL || box.IsContainable {
+				continue
+			}
+			box.DataPosition += shift
+			if !resized && match(box) {
+				box.DataSize += diff
+				resized, shift = true, int64(diff)
+			}
+			if !yield(box, nil) {
+				return
+			}
+		}
+	}
+}
+
+func ilstItemHeaderSize(id string) int32 {
+	return 8 /* item box header */ + int32(len(ilst.FreeformItemHeader(id)))
+}
@@ -0,0 +1,188 @@
+package qtffilst
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tingtt/qtffilst/ilst"
+)
+
+// RawItem is the item of `.moov.udta.meta.ilst` that ilst.ItemList does not support
+// (e.g. unknown box or freeform item).
+type RawItem struct {
+	// Box name, or "----:<mean>:<name>" for freeform item
+	Id string
+	// Data of the item box (`data` boxes, and `mean` and `name` boxes of freeform item)
+	Data []byte
+}
+
+// ReadRawItems reads the items of `.moov.udta.meta.ilst` that ilst.ItemList does not support.
+func (r *reader) ReadRawItems() ([]RawItem, error) {
+	items := []RawItem{}
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return nil, err
+		}
+		if /* item of ilst */ box.Level != 4 || box.IsContainable || !strings.HasPrefix(box.Path, ".moov.udta.meta.ilst.") {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		err = copy(r.f, box.DataPosition, box.DataSize, buf)
+		if err != nil {
+			return nil, err
+		}
+		id := rawItemId(box.Name, buf.Bytes())
+		if supportedIlstItem(id) {
+			continue
+		}
+		items = append(items, RawItem{id, buf.Bytes()})
+	}
+	return items, nil
+}
+
+// WriteRawItems writes the items directly under `.moov.udta.meta.ilst` without decoding.
+// The first item of each id in items is replaced (or appended if it does not exist),
+// and all the items of deleteIds are removed.
+// `.moov.udta.meta.ilst` is created if it does not exist.
+func (r *readWriter) WriteRawItems(dest, tmpDest *os.File, items []RawItem, deleteIds []string) error {
+	if len(items) == 0 && len(deleteIds) == 0 {
+		_, err := r.f.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dest, r.f)
+		return err
+	}
+
+	remainingItems := slices.Clone(items)
+	writeItem := func(w io.Writer, item RawItem) error {
+		name := item.Id
+		if _, _, freeform := ilst.ParseFreeformId(item.Id); freeform {
+			name = ilst.FreeformBoxName
+		}
+		return writeBox(w, name, item.Data)
+	}
+
+	layout := &ilstLayout{ilstExists: true}
+	if len(items) != 0 {
+		var err error
+		layout, err = readIlstLayout(r.f, r.size)
+		if err != nil {
+			return err
+		}
+	}
+
+	for box, err := range WritableWalk(r.f, r.size, tmpDest) {
+		if err != nil {
+			return err
+		}
+		if !layout.ilstExists {
+			// create `.moov.udta.meta.ilst` with the items
+			children := &bytes.Buffer{}
+			for _, item := range remainingItems {
+				err = writeItem(children, item)
+				if err != nil {
+					return err
+				}
+			}
+			created, err := layout.createIlst(r.f, box, children.Bytes())
+			if err != nil {
+				return err
+			}
+			if !created {
+				continue
+			}
+			for _, item := range remainingItems {
+				slog.Info("append", slog.String("id", item.Id), slog.String("diff", fmt.Sprintf("%+d", len(item.Data)+8)))
+			}
+			remainingItems = nil
+			layout.ilstExists = true
+			continue
+		}
+		if box.Path != ".moov.udta.meta.ilst" {
+			continue
+		}
+
+		// rebuild children of `.moov.udta.meta.ilst`
+		children := &bytes.Buffer{}
+		for offset := box.DataPosition; offset < box.DataPosition+int64(box.DataSize); {
+			_, err = r.f.Seek(offset, io.SeekStart)
+			if err != nil {
+				return err
+			}
+			size, name, err := readBoxHeader(r.f)
+			if err != nil {
+				return err
+			}
+			if size < 8 {
+				return fmt.Errorf("invalid box size (%s)", box.Path)
+			}
+			data := &bytes.Buffer{}
+			err = copy(r.f, offset+8, size-8, data)
+			if err != nil {
+				return err
+			}
+			id := rawItemId(name, data.Bytes())
+
+			index := slices.IndexFunc(remainingItems, func(item RawItem) bool { return item.Id == id })
+			switch {
+			case slices.Contains(deleteIds, id):
+				slog.Info("remove", slog.String("id", id), slog.String("diff", fmt.Sprintf("%+d", -size)))
+			case index != -1:
+				err = writeItem(children, remainingItems[index])
+				slog.Info("modify", slog.String("id", id), slog.String("diff", fmt.Sprintf("%+d", int32(len(remainingItems[index].Data)+8)-size)))
+				remainingItems = slices.Delete(remainingItems, index, index+1)
+			default:
+				err = copy(r.f, offset, size, children)
+			}
+			if err != nil {
+				return err
+			}
+			offset += int64(size)
+		}
+		for _, item := range remainingItems {
+			err = writeItem(children, item)
+			if err != nil {
+				return err
+			}
+			slog.Info("append", slog.String("id", item.Id), slog.String("diff", fmt.Sprintf("%+d", len(item.Data)+8)))
+		}
+		remainingItems = nil
+
+		_, err = box.Write(children.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+	if len(remainingItems) != 0 {
+		return ErrIlstBoxDoesNotExist
+	}
+
+	return r.copyWithOffsetsPatch(tmpDest, dest)
+}
+
+// rawItemId returns the id of the item box of the name and the data.
+func rawItemId(name string, data []byte) string {
+	if name != ilst.FreeformBoxName {
+		return name
+	}
+	mean, itemName := "", ""
+	for childName, childData := range childBoxes(data) {
+		if len(childData) < 4 /* version, flags */ {
+			continue
+		}
+		switch childName {
+		case "mean":
+			mean = string(childData[4:])
+		case "name":
+			itemName = string(childData[4:])
+		}
+	}
+	return ilst.NewFreeformId(mean, itemName)
+}
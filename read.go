@@ -10,13 +10,26 @@ import (
 	"strings"
 
 	"github.com/tingtt/iterutil"
+	"github.com/tingtt/qtffilst/chapter"
+	"github.com/tingtt/qtffilst/id3"
 	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/mdta"
+	"github.com/tingtt/qtffilst/udta"
 
 	"gitlab.com/osaki-lab/iowrapper"
 )
 
 type Reader interface {
+	ReadFileType() (FileType, error)
 	Read() (ilst.ItemList, error)
+	ReadRawItems() ([]RawItem, error)
+	ReadMetadata() (mdta.Metadata, error)
+	ReadUserData() (udta.UserData, error)
+	ReadAssets() (udta.Assets, error)
+	ReadXMP() ([]byte, error)
+	ReadID32() (*id3.ID32, error)
+	ReadChapters() (chapter.Chapters, error)
+	ReadMediaInfo() (MediaInfo, error)
 }
 
 func NewReader(f fs.File) (Reader, error) {
@@ -45,13 +58,21 @@ func (r *reader) Read() (ilst.ItemList, error) {
 			return ilst.ItemList{}, err
 		}
 
+		ilstBoxName, err := ilstItemId(r.f, box)
+		if err != nil {
+			return ilst.ItemList{}, err
+		}
+		if !supportedIlstItem(ilstBoxName) {
+			slog.Debug(fmt.Sprintf("box: %-36s (%v, %vB) unsupported item (%s)\n", box.Path, box.DataPosition, box.DataSize, ilstBoxName))
+			continue
+		}
+
 		buf := &bytes.Buffer{}
 		err = copy(r.f, box.DataPosition, box.DataSize, buf)
 		if err != nil {
 			return ilst.ItemList{}, err
 		}
 
-		ilstBoxName := ilstDataBoxName(box.Path)
 		err = itemList.SetDecoded(ilstBoxName, buf.Bytes())
 		if err != nil {
 			return ilst.ItemList{}, fmt.Errorf("%w (id: %s)", err, box.Name)
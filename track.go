@@ -0,0 +1,298 @@
+package qtffilst
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var ErrAudioTrackDoesNotExist = errors.New("audio track does not exists")
+
+type AudioTrackDuration struct {
+	// Time scale in `.moov.trak.mdia.mdhd`
+	Timescale int64
+	// Duration in `.moov.trak.mdia.mdhd`
+	Duration int64
+	// Total duration of the samples in `.moov.trak.mdia.minf.stbl.stts`
+	SampleDuration int64
+}
+
+// ReadAudioTrackDuration reads the duration of the first audio track.
+func ReadAudioTrackDuration(rs io.ReadSeeker, size int64) (AudioTrackDuration, error) {
+	var (
+		handlerType string
+		duration    AudioTrackDuration
+	)
+	for box, err := range Walk(rs, size) {
+		if err != nil {
+			return AudioTrackDuration{}, err
+		}
+
+		switch box.Path {
+		case ".moov.trak":
+			if !box.IsContainable /* start of track */ {
+				handlerType, duration = "", AudioTrackDuration{}
+				continue
+			}
+			if handlerType == "soun" {
+				return duration, nil
+			}
+		case ".moov.trak.mdia.hdlr":
+			handlerType, err = readHandlerType(rs, box)
+			if err != nil {
+				return AudioTrackDuration{}, err
+			}
+		case ".moov.trak.mdia.mdhd":
+			duration.Timescale, duration.Duration, err = readMediaHeader(rs, box)
+			if err != nil {
+				return AudioTrackDuration{}, err
+			}
+		case ".moov.trak.mdia.minf.stbl.stts":
+			duration.SampleDuration, err = readSampleDuration(rs, box)
+			if err != nil {
+				return AudioTrackDuration{}, err
+			}
+		}
+	}
+	return AudioTrackDuration{}, ErrAudioTrackDoesNotExist
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/handler_reference_atom
+func readHandlerType(rs io.ReadSeeker, box Box) (string, error) {
+	_, err := rs.Seek(box.DataPosition+8 /* version, flags, component type */, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+	buf, err := binary.Read(rs, 4)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/media_header_atom
+func readMediaHeader(rs io.ReadSeeker, box Box) (timescale, duration int64, err error) {
+	_, err = rs.Seek(box.DataPosition, io.SeekStart)
+	if err != nil {
+		return 0, 0, err
+	}
+	version, err := binary.Read(rs, 4 /* version, flags */)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if version[0] == 1 {
+		_, err = rs.Seek(16 /* creation time, modification time */, io.SeekCurrent)
+		if err != nil {
+			return 0, 0, err
+		}
+		timescale32, err := binary.BigEdian.ReadI32(rs)
+		if err != nil {
+			return 0, 0, err
+		}
+		duration, err = binary.BigEdian.ReadI64(rs)
+		return int64(uint32(timescale32)), duration, err
+	}
+
+	_, err = rs.Seek(8 /* creation time, modification time */, io.SeekCurrent)
+	if err != nil {
+		return 0, 0, err
+	}
+	timescale32, err := binary.BigEdian.ReadI32(rs)
+	if err != nil {
+		return 0, 0, err
+	}
+	duration32, err := binary.BigEdian.ReadI32(rs)
+	return int64(uint32(timescale32)), int64(uint32(duration32)), err
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/time-to-sample_atom
+func readSampleDuration(rs io.ReadSeeker, box Box) (int64, error) {
+	_, err := rs.Seek(box.DataPosition+4 /* version, flags */, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	entryCount, err := binary.BigEdian.ReadI32(rs)
+	if err != nil {
+		return 0, err
+	}
+	err = checkEntryCount(box, 8 /* version, flags, entry count */, entryCount, 8)
+	if err != nil {
+		return 0, err
+	}
+
+	total := int64(0)
+	for range entryCount {
+		sampleCount, err := binary.BigEdian.ReadI32(rs)
+		if err != nil {
+			return 0, err
+		}
+		sampleDuration, err := binary.BigEdian.ReadI32(rs)
+		if err != nil {
+			return 0, err
+		}
+		total += int64(uint32(sampleCount)) * int64(uint32(sampleDuration))
+	}
+	return total, nil
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/track_header_atom
+func readTrackId(rs io.ReadSeeker, box Box) (int32, error) {
+	_, err := rs.Seek(box.DataPosition, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	version, err := binary.Read(rs, 4 /* version, flags */)
+	if err != nil {
+		return 0, err
+	}
+	skip := int64(8) /* creation time, modification time */
+	if version[0] == 1 {
+		skip = 16
+	}
+	_, err = rs.Seek(skip, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEdian.ReadI32(rs)
+}
+
+// readTrackReferences reads the track ids of the reference type in `.moov.trak.tref`.
+// https://developer.apple.com/documentation/quicktime-file-format/track_reference_atom
+func readTrackReferences(rs io.ReadSeeker, box Box, referenceType string) ([]int32, error) {
+	trackIds := []int32{}
+	for offset := box.DataPosition; offset < box.DataPosition+int64(box.DataSize); {
+		_, err := rs.Seek(offset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		size, name, err := readBoxHeader(rs)
+		if err != nil {
+			return nil, err
+		}
+		if size < 8 {
+			return nil, fmt.Errorf("invalid box size (%s: %d)", name, size)
+		}
+		if name == referenceType {
+			for range (size - 8) / 4 {
+				trackId, err := binary.BigEdian.ReadI32(rs)
+				if err != nil {
+					return nil, err
+				}
+				trackIds = append(trackIds, trackId)
+			}
+		}
+		offset += int64(size)
+	}
+	return trackIds, nil
+}
+
+// readSampleSizes reads the size of each sample in `.moov.trak.mdia.minf.stbl.stsz`, up to maxCount samples.
+// https://developer.apple.com/documentation/quicktime-file-format/sample_size_atom
+func readSampleSizes(rs io.ReadSeeker, box Box, maxCount int64) ([]int64, error) {
+	_, err := rs.Seek(box.DataPosition+4 /* version, flags */, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	sampleSize, err := binary.BigEdian.ReadI32(rs)
+	if err != nil {
+		return nil, err
+	}
+	entryCount, err := binary.BigEdian.ReadI32(rs)
+	if err != nil {
+		return nil, err
+	}
+	if /* sizes of samples follow */ sampleSize == 0 {
+		err = checkEntryCount(box, 12 /* version, flags, sample size, entry count */, entryCount, 4)
+	} else {
+		// samples of the same size cannot exceed the file
+		err = checkConstantSizeEntryCount(rs, box, entryCount, int64(uint32(sampleSize)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	count := min(int64(entryCount), maxCount)
+	sizes := make([]int64, 0, count)
+	for range count {
+		if /* all samples have the same size */ sampleSize != 0 {
+			sizes = append(sizes, int64(uint32(sampleSize)))
+			continue
+		}
+		size, err := binary.BigEdian.ReadI32(rs)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, int64(uint32(size)))
+	}
+	return sizes, nil
+}
+
+// readSampleOffsets reads the position of each sample from `stsc`, `stsz` and `stco` (or `co64`) boxes, up to maxCount samples.
+// https://developer.apple.com/documentation/quicktime-file-format/sample-to-chunk_atom
+func readSampleOffsets(rs io.ReadSeeker, stscBox, stszBox, chunkOffsetBox Box, maxCount int64) (offsets, sizes []int64, err error) {
+	sampleToChunk, err := readTableEntries(rs, stscBox, 3)
+	if err != nil {
+		return nil, nil, err
+	}
+	sizes, err = readSampleSizes(rs, stszBox, maxCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	chunkOffsets, err := readChunkOffsets(rs, chunkOffsetBox)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	offsets = make([]int64, 0, len(sizes))
+	if len(sampleToChunk) == 0 {
+		return offsets, sizes[:0], nil
+	}
+	entryIndex := 0
+	for chunk, chunkOffset := range chunkOffsets {
+		for entryIndex+1 < len(sampleToChunk) && sampleToChunk[entryIndex+1][0] /* first chunk */ <= int64(chunk+1) {
+			entryIndex++
+		}
+		offset := chunkOffset
+		for range sampleToChunk[entryIndex][1] /* samples per chunk */ {
+			if len(offsets) == len(sizes) {
+				return offsets, sizes, nil
+			}
+			offsets = append(offsets, offset)
+			offset += sizes[len(offsets)-1]
+		}
+	}
+	return offsets, sizes[:len(offsets)], nil
+}
+
+// readTableEntries reads the entries of the table that has 32 bit unsigned integer fields.
+func readTableEntries(rs io.ReadSeeker, box Box, fieldCount int) ([][]int64, error) {
+	_, err := rs.Seek(box.DataPosition+4 /* version, flags */, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	entryCount, err := binary.BigEdian.ReadI32(rs)
+	if err != nil {
+		return nil, err
+	}
+	err = checkEntryCount(box, 8 /* version, flags, entry count */, entryCount, int32(4*fieldCount))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([][]int64, 0, entryCount)
+	for range entryCount {
+		entry := make([]int64, 0, fieldCount)
+		for range fieldCount {
+			value, err := binary.BigEdian.ReadI32(rs)
+			if err != nil {
+				return nil, err
+			}
+			entry = append(entry, int64(uint32(value)))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,516 @@
+package udta
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+// Assets is the 3GPP asset information boxes directly under `.moov.udta`.
+// https://www.3gpp.org/ftp/Specs/archive/26_series/26.244/ (8.2 Asset information)
+// Only the first box of each type is supported (variants of other languages are ignored).
+type Assets struct {
+	Title         *AssetText     `id:"titl" name:"title"`
+	Description   *AssetText     `id:"dscp" name:"description"`
+	Copyright     *AssetText     `id:"cprt" name:"copyright"`
+	Performer     *AssetText     `id:"perf" name:"performer"`
+	Author        *AssetText     `id:"auth" name:"author"`
+	Genre         *AssetText     `id:"gnre" name:"genre"`
+	RecordingYear *RecordingYear `id:"yrrc" name:"recording_year"`
+	Location      *Location      `id:"loci" name:"location"`
+	Keywords      *Keywords      `id:"kywd" name:"keywords"`
+	Album         *Album         `id:"albm" name:"album"`
+}
+
+// AssetIds returns the box ids of Assets.
+func AssetIds() iter.Seq[string] {
+	return func(yield func(id string) bool) {
+		rt := reflect.TypeOf(Assets{})
+		for i := range rt.NumField() {
+			if !yield(rt.Field(i).Tag.Get("id")) {
+				return
+			}
+		}
+	}
+}
+
+// ResolveAssetId returns the box id of Assets for the given id, friendly name or field name.
+func ResolveAssetId(name string) (id string, ok bool) {
+	rt := reflect.TypeOf(Assets{})
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		if name == f.Tag.Get("id") || name == f.Tag.Get("name") || name == f.Name {
+			return f.Tag.Get("id"), true
+		}
+	}
+	return "", false
+}
+
+// AssetName returns the friendly name of the Assets box id.
+func AssetName(id string) string {
+	rt := reflect.TypeOf(Assets{})
+	for i := range rt.NumField() {
+		if rt.Field(i).Tag.Get("id") == id {
+			return rt.Field(i).Tag.Get("name")
+		}
+	}
+	return ""
+}
+
+// EncodedValues iterates the box ids and the box data of Assets that are not nil.
+func (a *Assets) EncodedValues() iter.Seq2[string, []byte] {
+	return func(yield func(string, []byte) bool) {
+		rv := reflect.ValueOf(a).Elem()
+		rt := rv.Type()
+		for i := range rt.NumField() {
+			if rv.Field(i).IsNil() {
+				continue
+			}
+			if !yield(rt.Field(i).Tag.Get("id"), rv.Field(i).Interface().(asset).Bytes()) {
+				return
+			}
+		}
+	}
+}
+
+// SetDecoded decodes the data of the box and sets to Assets.
+// The box that is already set is ignored.
+func (a *Assets) SetDecoded(id string, data []byte) error {
+	field, ok := a.field(id)
+	if !ok {
+		return fmt.Errorf("unsupported 3GPP asset (\"%s\")", id)
+	}
+	if !field.IsNil() {
+		return nil
+	}
+	value := reflect.New(field.Type().Elem())
+	err := value.Interface().(asset).decode(data)
+	if err != nil {
+		return err
+	}
+	field.Set(value)
+	return nil
+}
+
+// Set parses the text and sets to Assets.
+func (a *Assets) Set(id string, str string, language Language) error {
+	field, ok := a.field(id)
+	if !ok {
+		return fmt.Errorf("unsupported 3GPP asset (\"%s\")", id)
+	}
+	value := reflect.New(field.Type().Elem())
+	err := value.Interface().(asset).parse(str, language)
+	if err != nil {
+		return err
+	}
+	field.Set(value)
+	return nil
+}
+
+func (a *Assets) field(id string) (reflect.Value, bool) {
+	rv := reflect.ValueOf(a).Elem()
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		if rt.Field(i).Tag.Get("id") == id {
+			return rv.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Validate reports the error if any of the assets cannot be written.
+func (a *Assets) Validate() error {
+	rv := reflect.ValueOf(a).Elem()
+	for i := range rv.NumField() {
+		if rv.Field(i).IsNil() {
+			continue
+		}
+		v, ok := rv.Field(i).Interface().(interface{ validate() error })
+		if !ok {
+			continue
+		}
+		err := v.validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type asset interface {
+	// Bytes returns the data of the full box (including version and flags).
+	Bytes() []byte
+	decode(data []byte) error
+	parse(str string, language Language) error
+}
+
+// AssetText is the text with language (`titl`, `dscp`, `cprt`, `perf`, `auth`, `gnre`).
+type AssetText struct {
+	Language Language
+	Text     string
+}
+
+func (t *AssetText) decode(data []byte) error {
+	r, err := newAssetReader(data)
+	if err != nil {
+		return err
+	}
+	t.Language, err = r.language()
+	if err != nil {
+		return err
+	}
+	t.Text, err = r.string()
+	return err
+}
+
+func (t *AssetText) parse(str string, language Language) error {
+	t.Language, t.Text = language, str
+	return nil
+}
+
+func (t AssetText) Bytes() []byte {
+	buf := newAssetBuffer()
+	buf.language(t.Language)
+	buf.string(t.Text)
+	return buf.Bytes()
+}
+
+// RecordingYear is the year of the recording (`yrrc`).
+type RecordingYear struct {
+	Year uint16
+}
+
+func (y *RecordingYear) decode(data []byte) error {
+	r, err := newAssetReader(data)
+	if err != nil {
+		return err
+	}
+	y.Year, err = r.uint16()
+	return err
+}
+
+func (y *RecordingYear) parse(str string, _ Language) error {
+	year, err := strconv.ParseUint(str, 10, 16)
+	if err != nil {
+		return err
+	}
+	y.Year = uint16(year)
+	return nil
+}
+
+func (y RecordingYear) Bytes() []byte {
+	buf := newAssetBuffer()
+	buf.Write(binary.BigEdian.BytesI16(int16(y.Year)))
+	return buf.Bytes()
+}
+
+// Album is the album title and the track number (`albm`).
+// Text format: "<title>" or "<title>;track=<track number>" (e.g. "Live 24/7;track=3")
+type Album struct {
+	Language Language
+	Title    string
+	// 0 if the track number does not exist
+	TrackNumber uint8
+}
+
+func (a *Album) decode(data []byte) error {
+	r, err := newAssetReader(data)
+	if err != nil {
+		return err
+	}
+	a.Language, err = r.language()
+	if err != nil {
+		return err
+	}
+	a.Title, err = r.string()
+	if err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		a.TrackNumber, err = r.uint8()
+	}
+	return err
+}
+
+const albumTrackNumberSeparator = ";track="
+
+func (a *Album) parse(str string, language Language) error {
+	a.Language, a.Title = language, str
+	if i := strings.LastIndex(str, albumTrackNumberSeparator); i != -1 {
+		trackNumber, err := strconv.ParseUint(str[i+len(albumTrackNumberSeparator):], 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid album track number (\"%s\")", str)
+		}
+		a.Title, a.TrackNumber = str[:i], uint8(trackNumber)
+	}
+	return nil
+}
+
+func (a Album) Bytes() []byte {
+	buf := newAssetBuffer()
+	buf.language(a.Language)
+	buf.string(a.Title)
+	if a.TrackNumber != 0 {
+		buf.WriteByte(a.TrackNumber)
+	}
+	return buf.Bytes()
+}
+
+// Keywords is the keywords with language (`kywd`).
+// Text format: "<keyword>,<keyword>,..."
+type Keywords struct {
+	Language Language
+	Keywords []string
+}
+
+func (k *Keywords) decode(data []byte) error {
+	r, err := newAssetReader(data)
+	if err != nil {
+		return err
+	}
+	k.Language, err = r.language()
+	if err != nil {
+		return err
+	}
+	count, err := r.uint8()
+	if err != nil {
+		return err
+	}
+	for range count {
+		size, err := r.uint8()
+		if err != nil {
+			return err
+		}
+		if r.Len() < int(size) {
+			return ErrInvalidLength
+		}
+		keyword, err := (&assetReader{bytes.NewReader(r.next(int(size)))}).string()
+		if err != nil {
+			return err
+		}
+		k.Keywords = append(k.Keywords, keyword)
+	}
+	return nil
+}
+
+func (k *Keywords) parse(str string, language Language) error {
+	k.Language, k.Keywords = language, strings.Split(str, ",")
+	return k.validate()
+}
+
+// validate reports the error if the keywords cannot be written
+// (count and size of each keyword including null terminator are 8 bits).
+func (k Keywords) validate() error {
+	if len(k.Keywords) > math.MaxUint8 {
+		return fmt.Errorf("%w (%d)", ErrTooManyKeywords, len(k.Keywords))
+	}
+	for _, keyword := range k.Keywords {
+		if len(keyword)+1 /* null terminator */ > math.MaxUint8 {
+			return fmt.Errorf("%w (%dB)", ErrKeywordTooLong, len(keyword))
+		}
+	}
+	return nil
+}
+
+func (k Keywords) Bytes() []byte {
+	buf := newAssetBuffer()
+	buf.language(k.Language)
+	buf.WriteByte(uint8(len(k.Keywords)))
+	for _, keyword := range k.Keywords {
+		buf.WriteByte(uint8(len(keyword) + 1 /* null terminator */))
+		buf.string(keyword)
+	}
+	return buf.Bytes()
+}
+
+// Location is the location information (`loci`).
+// Text format: ISO 6709 (e.g. "+35.6812+139.7671+040.000/")
+type Location struct {
+	Language Language
+	Name     string
+	// 0: shooting location, 1: real location, 2: fictional location
+	Role             uint8
+	Longitude        float64
+	Latitude         float64
+	Altitude         float64
+	AstronomicalBody string
+	AdditionalNotes  string
+}
+
+func (l *Location) decode(data []byte) error {
+	r, err := newAssetReader(data)
+	if err != nil {
+		return err
+	}
+	l.Language, err = r.language()
+	if err != nil {
+		return err
+	}
+	l.Name, err = r.string()
+	if err != nil {
+		return err
+	}
+	l.Role, err = r.uint8()
+	if err != nil {
+		return err
+	}
+	for _, v := range []*float64{&l.Longitude, &l.Latitude, &l.Altitude} {
+		*v, err = r.fixed16_16()
+		if err != nil {
+			return err
+		}
+	}
+	l.AstronomicalBody, err = r.string()
+	if err != nil {
+		return err
+	}
+	l.AdditionalNotes, err = r.string()
+	return err
+}
+
+func (l *Location) parse(str string, language Language) error {
+	values := []float64{}
+	rest := strings.TrimSuffix(strings.TrimSpace(str), "/")
+	for rest != "" {
+		end := strings.IndexAny(rest[1:], "+-") + 1
+		if end == 0 {
+			end = len(rest)
+		}
+		value, err := strconv.ParseFloat(rest[:end], 64)
+		if err != nil {
+			return fmt.Errorf("invalid ISO 6709 location (\"%s\")", str)
+		}
+		values = append(values, value)
+		rest = rest[end:]
+	}
+	if len(values) < 2 || len(values) > 3 {
+		return fmt.Errorf("invalid ISO 6709 location (\"%s\")", str)
+	}
+	*l = Location{Language: language, Latitude: values[0], Longitude: values[1], AstronomicalBody: "earth"}
+	if len(values) == 3 {
+		l.Altitude = values[2]
+	}
+	return nil
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("{Name:%s Role:%d Location:%+.4f%+.4f%+.3f/ AstronomicalBody:%s AdditionalNotes:%s}",
+		l.Name, l.Role, l.Latitude, l.Longitude, l.Altitude, l.AstronomicalBody, l.AdditionalNotes,
+	)
+}
+
+func (l Location) Bytes() []byte {
+	buf := newAssetBuffer()
+	buf.language(l.Language)
+	buf.string(l.Name)
+	buf.WriteByte(l.Role)
+	for _, v := range []float64{l.Longitude, l.Latitude, l.Altitude} {
+		buf.Write(binary.BigEdian.BytesI32(int32(math.Round(v * 65536))))
+	}
+	buf.string(l.AstronomicalBody)
+	buf.string(l.AdditionalNotes)
+	return buf.Bytes()
+}
+
+type assetReader struct {
+	*bytes.Reader
+}
+
+func newAssetReader(data []byte) (*assetReader, error) {
+	if len(data) < 4 {
+		return nil, ErrInvalidLength
+	}
+	return &assetReader{bytes.NewReader(data[4: /* version, flags */])}, nil
+}
+
+func (r *assetReader) next(n int) []byte {
+	buf := make([]byte, n)
+	n, _ = r.Read(buf)
+	return buf[:n]
+}
+
+func (r *assetReader) uint8() (uint8, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, ErrInvalidLength
+	}
+	return b, nil
+}
+
+func (r *assetReader) uint16() (uint16, error) {
+	v, err := binary.BigEdian.ReadI16(r)
+	if err != nil {
+		return 0, ErrInvalidLength
+	}
+	return uint16(v), nil
+}
+
+func (r *assetReader) fixed16_16() (float64, error) {
+	v, err := binary.BigEdian.ReadI32(r)
+	if err != nil {
+		return 0, ErrInvalidLength
+	}
+	return float64(v) / 65536, nil
+}
+
+// language reads the pad bit and the packed ISO 639-2/T language code.
+func (r *assetReader) language() (Language, error) {
+	v, err := r.uint16()
+	return Language(v & 0x7FFF), err
+}
+
+// string reads the null-terminated UTF-8 string, or UTF-16 string that starts with BOM.
+// The string without null terminator at the end of the data is also accepted.
+func (r *assetReader) string() (string, error) {
+	data := r.next(r.Len())
+	if len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF {
+		u := []uint16{}
+		rest := bytes.NewReader(data[2:])
+		for rest.Len() >= 2 {
+			v, err := binary.BigEdian.ReadI16(rest)
+			if err != nil {
+				return "", err
+			}
+			if v == 0 {
+				break
+			}
+			u = append(u, uint16(v))
+		}
+		r.Reset(data[len(data)-rest.Len():])
+		return string(utf16.Decode(u)), nil
+	}
+	end := bytes.IndexByte(data, 0x0)
+	if end == -1 {
+		r.Reset(nil)
+		return string(data), nil
+	}
+	r.Reset(data[end+1:])
+	return string(data[:end]), nil
+}
+
+type assetBuffer struct {
+	bytes.Buffer
+}
+
+func newAssetBuffer() *assetBuffer {
+	buf := &assetBuffer{}
+	buf.Write(make([]byte, 4) /* version, flags */)
+	return buf
+}
+
+func (b *assetBuffer) language(language Language) {
+	b.Write(binary.BigEdian.BytesI16(int16(language & 0x7FFF)))
+}
+
+// string writes the null-terminated UTF-8 string.
+func (b *assetBuffer) string(str string) {
+	b.WriteString(str)
+	b.WriteByte(0x0)
+}
@@ -0,0 +1,165 @@
+package udta
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const languageJapanese Language = 0x2A0E // packed "jpn"
+
+func TestLocationParse(t *testing.T) {
+	tests := []struct {
+		str                           string
+		latitude, longitude, altitude float64
+	}{
+		{"+35.6812+139.7671/", 35.6812, 139.7671, 0},
+		{"+35.6812-139.7671+10.5/", 35.6812, -139.7671, 10.5},
+		{" -33.8568+151.2153+040.000/ ", -33.8568, 151.2153, 40},
+		{"+35.6812+139.7671", 35.6812, 139.7671, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			l := Location{}
+			if err := l.parse(tt.str, LanguageUndetermined); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if l.Latitude != tt.latitude || l.Longitude != tt.longitude || l.Altitude != tt.altitude {
+				t.Errorf("location = %+v, want %v %v %v", l, tt.latitude, tt.longitude, tt.altitude)
+			}
+			if l.AstronomicalBody != "earth" {
+				t.Errorf("astronomical body = %q, want \"earth\"", l.AstronomicalBody)
+			}
+		})
+	}
+}
+
+func TestLocationParseError(t *testing.T) {
+	for _, str := range []string{"", "/", "+35.6812/", "35.6812 139.7671", "+35.68a+139.76/", "+1+2+3+4/"} {
+		t.Run(str, func(t *testing.T) {
+			l := Location{}
+			if err := l.parse(str, LanguageUndetermined); err == nil {
+				t.Errorf("location = %+v, want error", l)
+			}
+		})
+	}
+}
+
+func TestAssetRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		asset asset
+	}{
+		{"text", &AssetText{languageJapanese, "タイトル"}},
+		{"recording year", &RecordingYear{2020}},
+		{"album with track number", &Album{LanguageUndetermined, "Live 24/7", 3}},
+		{"album without track number", &Album{LanguageUndetermined, "Live", 0}},
+		{"keywords", &Keywords{LanguageUndetermined, []string{"live", "tokyo"}}},
+		{"location", &Location{LanguageUndetermined, "Home", 1, 139.7671, 35.6812, -10.5, "earth", "notes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := reflect.New(reflect.TypeOf(tt.asset).Elem()).Interface().(asset)
+			if err := decoded.decode(tt.asset.Bytes()); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if l, ok := decoded.(*Location); ok {
+				// fixed-point 16.16 loses precision
+				want := *tt.asset.(*Location)
+				for _, v := range []struct{ got, want float64 }{{l.Longitude, want.Longitude}, {l.Latitude, want.Latitude}, {l.Altitude, want.Altitude}} {
+					if math.Abs(v.got-v.want) > 1.0/65536 {
+						t.Errorf("location = %+v, want %+v", l, want)
+					}
+				}
+				l.Longitude, l.Latitude, l.Altitude = want.Longitude, want.Latitude, want.Altitude
+			}
+			if !reflect.DeepEqual(decoded, tt.asset) {
+				t.Errorf("decoded = %+v, want %+v", decoded, tt.asset)
+			}
+		})
+	}
+}
+
+func TestAlbumParse(t *testing.T) {
+	tests := []struct {
+		str     string
+		want    Album
+		wantErr bool
+	}{
+		{"Live 24/7;track=3", Album{LanguageUndetermined, "Live 24/7", 3}, false},
+		{"Live", Album{LanguageUndetermined, "Live", 0}, false},
+		{"A;track=1;track=2", Album{LanguageUndetermined, "A;track=1", 2}, false},
+		{"Live;track=abc", Album{}, true},
+		{"Live;track=256", Album{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got := Album{}
+			err := got.parse(tt.str, LanguageUndetermined)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("album = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeywordsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		keywords []string
+		wantErr  error
+	}{
+		{"255 keywords", slices.Repeat([]string{"a"}, 255), nil},
+		{"254 bytes keyword", []string{strings.Repeat("a", 254)}, nil},
+		{"too many keywords", slices.Repeat([]string{"a"}, 256), ErrTooManyKeywords},
+		{"too long keyword", []string{strings.Repeat("a", 255)}, ErrKeywordTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := Keywords{}
+			if err := k.parse(strings.Join(tt.keywords, ","), LanguageUndetermined); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssetDecodeError(t *testing.T) {
+	version := []byte{0, 0, 0, 0}
+	und := []byte{0x55, 0xC4}
+	tests := []struct {
+		name  string
+		asset asset
+		data  []byte
+	}{
+		{"without version and flags", &AssetText{}, []byte{0, 0}},
+		{"text without language", &AssetText{}, slices.Concat(version, []byte{0x55})},
+		{"keyword count exceeds data", &Keywords{}, slices.Concat(version, und, []byte{2, 2, 'a', 0})},
+		{"keyword size exceeds data", &Keywords{}, slices.Concat(version, und, []byte{1, 3, 'a', 0})},
+		{"location without coordinates", &Location{}, slices.Concat(version, und, []byte("Home\x00"), []byte{0, 0, 0, 0})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.asset.decode(tt.data); !errors.Is(err, ErrInvalidLength) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidLength)
+			}
+		})
+	}
+}
+
+func TestAssetTextDecodeUTF16(t *testing.T) {
+	data := []byte{0, 0, 0, 0, 0x55, 0xC4, 0xFE, 0xFF, 0, 'H', 0, 'i', 0, 0}
+	text := AssetText{}
+	if err := text.decode(data); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if text.Text != "Hi" {
+		t.Errorf("text = %q, want \"Hi\"", text.Text)
+	}
+}
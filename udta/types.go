@@ -0,0 +1,114 @@
+package udta
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var (
+	ErrInvalidLength   = errors.New("invalid length")
+	ErrKeywordTooLong  = errors.New("keyword exceeds 254 bytes")
+	ErrTooManyKeywords = errors.New("keywords exceed 255")
+)
+
+// TextAtom reports whether the box of the id is the classic user data text atom (e.g. "(c)nam").
+// https://developer.apple.com/documentation/quicktime-file-format/user_data_atoms
+func TextAtom(id string) bool {
+	return strings.HasPrefix(id, "(c)")
+}
+
+// Language is the language code of the user data text.
+// Values less than 0x400 are Macintosh language codes, others are packed ISO 639-2/T codes.
+// https://developer.apple.com/documentation/quicktime-file-format/language_code_values
+type Language uint16
+
+const (
+	LanguageEnglish Language = 0 // Macintosh language code
+	// Packed ISO 639-2/T code "und"
+	LanguageUndetermined Language = 0x55C4
+)
+
+// ParseLanguage parses ISO 639-2/T code (e.g. "eng") or Macintosh language code (e.g. "0").
+func ParseLanguage(str string) (Language, error) {
+	if code, err := strconv.ParseUint(str, 10, 16); err == nil {
+		if code >= 0x400 {
+			return 0, fmt.Errorf("invalid Macintosh language code (%d)", code)
+		}
+		return Language(code), nil
+	}
+	if len(str) != 3 {
+		return 0, fmt.Errorf("invalid language code (\"%s\")", str)
+	}
+	packed := uint16(0)
+	for _, c := range []byte(str) {
+		if c < 'a' || 'z' < c {
+			return 0, fmt.Errorf("invalid language code (\"%s\")", str)
+		}
+		packed = packed<<5 | uint16(c-0x60)
+	}
+	return Language(packed), nil
+}
+
+func (l Language) String() string {
+	if l < 0x400 {
+		return strconv.Itoa(int(l))
+	}
+	return string([]byte{
+		byte(l>>10&0x1F) + 0x60,
+		byte(l>>5&0x1F) + 0x60,
+		byte(l&0x1F) + 0x60,
+	})
+}
+
+// Text is a language variant of the classic user data text atom.
+type Text struct {
+	Language Language
+	Text     string
+}
+
+// DecodeTexts decodes the data of the classic user data text atom.
+// Data has a variant per language, and each variant is "<text size (16 bit)><language code (16 bit)><text>".
+func DecodeTexts(data []byte) ([]Text, error) {
+	texts := []Text{}
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		if r.Len() < 4 {
+			return nil, ErrInvalidLength
+		}
+		size, err := binary.BigEdian.ReadI16(r)
+		if err != nil {
+			return nil, err
+		}
+		language, err := binary.BigEdian.ReadI16(r)
+		if err != nil {
+			return nil, err
+		}
+		if r.Len() < int(uint16(size)) {
+			return nil, ErrInvalidLength
+		}
+		text, err := binary.Read(r, uint(uint16(size)))
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, Text{Language(uint16(language)), string(text)})
+	}
+	return texts, nil
+}
+
+func EncodeTexts(texts []Text) []byte {
+	buf := &bytes.Buffer{}
+	for _, text := range texts {
+		buf.Write(binary.BigEdian.BytesI16(int16(len(text.Text))))
+		buf.Write(binary.BigEdian.BytesI16(int16(text.Language)))
+		buf.Write([]byte(text.Text))
+	}
+	return buf.Bytes()
+}
+
+// UserData is the classic user data text atoms directly under `.moov.udta`.
+type UserData map[string][]Text
@@ -0,0 +1,189 @@
+package qtffilst
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/udta"
+)
+
+// ReadUserData reads the classic user data text atoms directly under `.moov.udta` (e.g. `.moov.udta.©nam`).
+func (r *reader) ReadUserData() (udta.UserData, error) {
+	userData := udta.UserData{}
+
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return nil, err
+		}
+		if !udtaTextAtomBox(box) {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		err = copy(r.f, box.DataPosition, box.DataSize, buf)
+		if err != nil {
+			return nil, err
+		}
+		texts, err := udta.DecodeTexts(buf.Bytes())
+		if err != nil {
+			slog.Debug(fmt.Sprintf("box: %-36s (%v, %vB) invalid user data text (%s)\n", box.Path, box.DataPosition, box.DataSize, err))
+			continue
+		}
+		userData[box.Name] = texts
+	}
+	return userData, nil
+}
+
+// WriteUserData writes the classic user data text atoms directly under `.moov.udta`.
+// `.moov.udta` is created if it does not exist.
+func (r *readWriter) WriteUserData(dest, tmpDest *os.File, userData udta.UserData, deleteIds []string) error {
+	boxes := map[string][]byte{}
+	for id, texts := range userData {
+		if len(texts) == 0 {
+			deleteIds = append(deleteIds, id)
+			continue
+		}
+		boxes[id] = udta.EncodeTexts(texts)
+	}
+	return r.writeUdtaBoxes(dest, tmpDest, boxes, deleteIds)
+}
+
+// writeUdtaBoxes writes the boxes directly under `.moov.udta`.
+// The first box of each id in boxes is replaced (or appended if it does not exist),
+// and all the boxes of deleteIds are removed.
+func (r *readWriter) writeUdtaBoxes(dest, tmpDest *os.File, boxes map[string][]byte, deleteIds []string) error {
+	if len(boxes) == 0 && len(deleteIds) == 0 {
+		_, err := r.f.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dest, r.f)
+		return err
+	}
+
+	var (
+		udtaExists bool
+		trakCount  int
+	)
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return err
+		}
+		switch {
+		case box.Path == ".moov.udta":
+			udtaExists = true
+		case box.Path == ".moov.trak" && box.IsContainable:
+			trakCount++
+		}
+	}
+	if !udtaExists && trakCount == 0 {
+		return ErrTrackDoesNotExist
+	}
+
+	remainingIds := slices.Sorted(maps.Keys(boxes))
+	appendRemainingBoxes := func(w io.Writer) error {
+		for _, id := range remainingIds {
+			err := writeBox(w, id, boxes[id])
+			if err != nil {
+				return err
+			}
+			slog.Info("append", slog.String("id", id), slog.String("diff", fmt.Sprintf("%+d", len(boxes[id])+8)))
+		}
+		remainingIds = nil
+		return nil
+	}
+
+	count := 0
+	for box, err := range WritableWalk(r.f, r.size, tmpDest) {
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case box.Path == ".moov.udta" && box.IsContainable:
+			// rebuild children of `.moov.udta`
+			children := &bytes.Buffer{}
+			for offset := box.DataPosition; offset < box.DataPosition+int64(box.DataSize); {
+				_, err = r.f.Seek(offset, io.SeekStart)
+				if err != nil {
+					return err
+				}
+				size, name, err := readBoxHeader(r.f)
+				if err != nil {
+					return err
+				}
+				switch {
+				case slices.Contains(deleteIds, name):
+					slog.Info("remove", slog.String("id", name), slog.String("diff", fmt.Sprintf("%+d", -size)))
+				case slices.Contains(remainingIds, name):
+					remainingIds = slices.DeleteFunc(remainingIds, func(id string) bool { return id == name })
+					err = writeBox(children, name, boxes[name])
+					slog.Info("modify", slog.String("id", name), slog.String("diff", fmt.Sprintf("%+d", int32(len(boxes[name])+8)-size)))
+				default:
+					err = copy(r.f, offset, size, children)
+				}
+				if err != nil {
+					return err
+				}
+				offset += int64(size)
+			}
+			err = appendRemainingBoxes(children)
+			if err != nil {
+				return err
+			}
+			_, err = box.Write(children.Bytes())
+		case box.Path == ".moov.trak" && box.IsContainable:
+			count++
+			if udtaExists || count != trakCount {
+				continue
+			}
+			// append `.moov.udta` after the last track
+			children := &bytes.Buffer{}
+			err = appendRemainingBoxes(children)
+			if err != nil {
+				return err
+			}
+			if children.Len() != 0 {
+				_, err = box.InsertNewBox("udta", children.Bytes())
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.copyWithOffsetsPatch(tmpDest, dest)
+}
+
+// MirrorUserData returns the classic user data text atoms changes that mirror the ItemList changes.
+// Text items of ItemList that have the classic user data text atom (e.g. `©nam`) are mirrored
+// to the first language variant, and other variants are kept.
+func MirrorUserData(itemList ilst.ItemList, deleteIds []string, current udta.UserData) (udta.UserData, []string) {
+	userData := udta.UserData{}
+	for id, value := range ilst.Values(&itemList) {
+		text, ok := ilst.Text(value)
+		if !ok || !udta.TextAtom(id) {
+			continue
+		}
+		texts := slices.Clone(current[id])
+		if len(texts) == 0 {
+			texts = []udta.Text{{Language: udta.LanguageUndetermined}}
+		}
+		texts[0].Text = text
+		userData[id] = texts
+	}
+
+	userDataDeleteIds := []string{}
+	for _, id := range deleteIds {
+		if udta.TextAtom(id) {
+			userDataDeleteIds = append(userDataDeleteIds, id)
+		}
+	}
+	return userData, userDataDeleteIds
+}
@@ -1,6 +1,49 @@
 package qtffilst
 
-import "strings"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/udta"
+	"github.com/tingtt/qtffilst/xmp"
+)
+
+var ErrInvalidEntryCount = errors.New("invalid entry count")
+
+// checkEntryCount checks that the table of entryCount entries (entrySize bytes each)
+// fits in the data of box after headerSize bytes, before allocating the entries.
+func checkEntryCount(box Box, headerSize, entryCount, entrySize int32) error {
+	if entryCount < 0 || int64(headerSize)+int64(entryCount)*int64(entrySize) > int64(box.DataSize) {
+		return fmt.Errorf("%w (%s: %d)", ErrInvalidEntryCount, box.Path, entryCount)
+	}
+	return nil
+}
+
+// checkConstantSizeEntryCount checks the entry count of the table without entries (e.g. `stsz` of the same size samples)
+// against the size of the file that the entries of entrySize occupy.
+func checkConstantSizeEntryCount(rs io.Seeker, box Box, entryCount int32, entrySize int64) error {
+	offset, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	fileSize, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	_, err = rs.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	if entryCount < 0 || int64(entryCount)*entrySize > fileSize {
+		return fmt.Errorf("%w (%s: %d)", ErrInvalidEntryCount, box.Path, entryCount)
+	}
+	return nil
+}
 
 func ilstDataBox(box Box) bool {
 	return strings.HasPrefix(box.Path, ".moov.udta.meta.ilst.") &&
@@ -8,6 +51,92 @@ func ilstDataBox(box Box) bool {
 		box.Name == "data"
 }
 
+// mdtaDataBox reports whether the box is `data` box of the `mdta` metadata item (`.moov.meta.ilst.<key index>.data`).
+func mdtaDataBox(box Box) bool {
+	return strings.HasPrefix(box.Path, ".moov.meta.ilst.") &&
+		box.Level == 4 &&
+		!box.IsContainable &&
+		box.Name == "data"
+}
+
+// udtaTextAtomBox reports whether the box is the classic user data text atom (`.moov.udta.©xxx`).
+func udtaTextAtomBox(box Box) bool {
+	return box.Level == 2 &&
+		box.Path == ".moov.udta."+box.Name &&
+		!box.IsContainable &&
+		udta.TextAtom(box.Name)
+}
+
+// udtaAssetBox reports whether the box is the 3GPP asset information box (e.g. `.moov.udta.titl`).
+func udtaAssetBox(box Box) bool {
+	return box.Level == 2 &&
+		box.Path == ".moov.udta."+box.Name &&
+		!box.IsContainable &&
+		slices.Contains(slices.Collect(udta.AssetIds()), box.Name)
+}
+
+// xmpBox reports whether the box is `uuid` box of XMP packet at the top level or under `.moov`.
+func xmpBox(box Box) bool {
+	return (box.Path == ".uuid" || box.Path == ".moov.uuid") &&
+		box.ExtendedType == xmp.UUID
+}
+
+// id32Box reports whether the box is the ID3v2 tag box (`.moov.udta.meta.ID32`).
+func id32Box(box Box) bool {
+	return box.Path == ".moov.udta.meta.ID32"
+}
+
 func ilstDataBoxName(path string) string {
 	return strings.Split(path, ".")[5]
 }
+
+// ilstItemId returns the id of the item that has the `data` box.
+// Id of freeform item is "----:<mean>:<name>".
+func ilstItemId(rs io.ReadSeeker, box Box) (string, error) {
+	name := ilstDataBoxName(box.Path)
+	if name != ilst.FreeformBoxName {
+		return name, nil
+	}
+
+	// read `mean` and `name` boxes before `data` box
+	mean := ""
+	offset := box.ParentDataPosition
+	for offset < box.DataPosition-8 {
+		_, err := rs.Seek(offset, io.SeekStart)
+		if err != nil {
+			return "", err
+		}
+		size, boxName, err := readBoxHeader(rs)
+		if err != nil {
+			return "", err
+		}
+		minSize := int32(8) /* size, name */
+		if boxName == "mean" || boxName == "name" {
+			minSize += 4 /* version, flags */
+		}
+		if size < minSize || offset+int64(size) > box.DataPosition-8 {
+			return "", fmt.Errorf("invalid box size (%s: %d)", strings.TrimSuffix(box.Path, "."+box.Name)+"."+boxName, size)
+		}
+		if boxName == "mean" || boxName == "name" {
+			buf := &bytes.Buffer{}
+			err = copy(rs, offset+8+4 /* version, flags */, size-8-4, buf)
+			if err != nil {
+				return "", err
+			}
+			if boxName == "mean" {
+				mean = buf.String()
+			} else {
+				name = buf.String()
+			}
+		}
+		offset += int64(size)
+	}
+	return ilst.NewFreeformId(mean, name), nil
+}
+
+// supportedIlstItem reports whether the item of the id is supported by ilst.ItemList.
+// Unsupported freeform items are skipped.
+func supportedIlstItem(id string) bool {
+	_, ok := ilst.ResolveId(id)
+	return ok
+}
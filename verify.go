@@ -0,0 +1,253 @@
+package qtffilst
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tingtt/qtffilst/ilst"
+	"github.com/tingtt/qtffilst/internal/binary"
+)
+
+var ErrVerificationFailed = errors.New("verification failed")
+
+// Verify verifies the file written by Write with the same tags and deleteIds.
+func (r *readWriter) Verify(dest *os.File, newItemList ilst.ItemList, deleteIds []string) error {
+	stat, err := dest.Stat()
+	if err != nil {
+		return err
+	}
+
+	err = verifyBoxNesting(Walk(dest, stat.Size()), stat.Size())
+	if err != nil {
+		return err
+	}
+	err = verifySampleData(r.f, r.size, dest, stat.Size())
+	if err != nil {
+		return err
+	}
+
+	expected, err := r.Read()
+	if err != nil {
+		return err
+	}
+	modifyItems := maps.Collect(ilst.Values(&newItemList))
+	for id, v := range ilst.IterateFieldWriters(&expected) {
+		if _, modify := modifyItems[id]; modify || slices.Contains(deleteIds, id) {
+			v.Remove()
+		}
+	}
+	for value, err := range ilst.EncodedValues(&newItemList) {
+		if err != nil {
+			return err
+		}
+		err = expected.SetDecoded(value.Id, value.Bytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	destReader := &reader{f: dest, size: stat.Size()}
+	actual, err := destReader.Read()
+	if err != nil {
+		return err
+	}
+	return verifyItemList(expected, actual)
+}
+
+func verifyBoxNesting(seq iter.Seq2[Box, error], size int64) error {
+	type container struct {
+		level int8
+		endAt int64
+	}
+	parents := []container{{ROOT_LEVEL - 1, size}}
+
+	for box, err := range seq {
+		if err != nil {
+			return err
+		}
+		if box.IsContainable /* yielded after walking children */ {
+			continue
+		}
+		for parents[len(parents)-1].level >= box.Level {
+			parents = parents[:len(parents)-1]
+		}
+
+		endAt := box.DataPosition + int64(box.DataSize)
+		if box.DataSize < 0 || endAt > parents[len(parents)-1].endAt {
+			return fmt.Errorf("%w: %s overflows parent box", ErrVerificationFailed, box.Path)
+		}
+		if containableBox(strings.TrimSuffix(box.Path, "."+box.Name), box.Name) {
+			parents = append(parents, container{box.Level, endAt})
+		}
+	}
+	return nil
+}
+
+func verifySampleData(src io.ReadSeeker, srcSize int64, dest io.ReadSeeker, destSize int64) error {
+	srcMdatBoxes, srcChunkOffsets, err := loadSampleDataLayout(src, srcSize)
+	if err != nil {
+		return err
+	}
+	destMdatBoxes, destChunkOffsets, err := loadSampleDataLayout(dest, destSize)
+	if err != nil {
+		return err
+	}
+
+	if len(srcMdatBoxes) != len(destMdatBoxes) {
+		return fmt.Errorf("%w: number of .mdat changed (%d -> %d)", ErrVerificationFailed, len(srcMdatBoxes), len(destMdatBoxes))
+	}
+	for i := range srcMdatBoxes {
+		equal, err := equalData(src, srcMdatBoxes[i], dest, destMdatBoxes[i])
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return fmt.Errorf("%w: sample data of .mdat changed", ErrVerificationFailed)
+		}
+	}
+
+	if len(srcChunkOffsets) != len(destChunkOffsets) {
+		return fmt.Errorf("%w: number of chunk offsets changed (%d -> %d)", ErrVerificationFailed, len(srcChunkOffsets), len(destChunkOffsets))
+	}
+	for i := range destChunkOffsets {
+		destMdatIndex := slices.IndexFunc(destMdatBoxes, containsOffset(destChunkOffsets[i]))
+		if destMdatIndex == -1 {
+			return fmt.Errorf("%w: chunk offset %d points outside of .mdat", ErrVerificationFailed, destChunkOffsets[i])
+		}
+		srcMdatIndex := slices.IndexFunc(srcMdatBoxes, containsOffset(srcChunkOffsets[i]))
+		if srcMdatIndex != destMdatIndex ||
+			srcChunkOffsets[i]-srcMdatBoxes[srcMdatIndex].DataPosition != destChunkOffsets[i]-destMdatBoxes[destMdatIndex].DataPosition {
+			return fmt.Errorf("%w: chunk offset %d points different sample data (source: %d)", ErrVerificationFailed, destChunkOffsets[i], srcChunkOffsets[i])
+		}
+	}
+	return nil
+}
+
+func loadSampleDataLayout(rs io.ReadSeeker, size int64) (mdatBoxes []Box, chunkOffsets []int64, err error) {
+	boxes := []Box{}
+	for box, err := range Walk(rs, size) {
+		if err != nil {
+			return nil, nil, err
+		}
+		boxes = append(boxes, box)
+	}
+
+	for _, box := range boxes {
+		switch box.Path {
+		case ".mdat":
+			mdatBoxes = append(mdatBoxes, box)
+		case ".moov.trak.mdia.minf.stbl.stco", ".moov.trak.mdia.minf.stbl.co64":
+			offsets, err := readChunkOffsets(rs, box)
+			if err != nil {
+				return nil, nil, err
+			}
+			chunkOffsets = append(chunkOffsets, offsets...)
+		}
+	}
+	return mdatBoxes, chunkOffsets, nil
+}
+
+// Data format
+// https://developer.apple.com/documentation/quicktime-file-format/chunk_offset_atom
+// https://developer.apple.com/documentation/quicktime-file-format/64-bit_chunk_offset_atom
+func readChunkOffsets(rs io.ReadSeeker, box Box) ([]int64, error) {
+	_, err := rs.Seek(box.DataPosition+4 /* version, flags */, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	entryCount, err := binary.BigEdian.ReadI32(rs)
+	if err != nil {
+		return nil, err
+	}
+	entrySize := int32(4)
+	if box.Name == "co64" {
+		entrySize = 8
+	}
+	err = checkEntryCount(box, 8 /* version, flags, number of entries */, entryCount, entrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make([]int64, 0, entryCount)
+	for range entryCount {
+		var offset int64
+		if box.Name == "co64" {
+			offset, err = binary.BigEdian.ReadI64(rs)
+		} else {
+			var offset32 int32
+			offset32, err = binary.BigEdian.ReadI32(rs)
+			offset = int64(uint32(offset32))
+		}
+		if err != nil {
+			return nil, err
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets, nil
+}
+
+func containsOffset(offset int64) func(Box) bool {
+	return func(box Box) bool {
+		return box.DataPosition <= offset && offset < box.DataPosition+int64(box.DataSize)
+	}
+}
+
+func equalData(a io.ReadSeeker, aBox Box, b io.ReadSeeker, bBox Box) (bool, error) {
+	if aBox.DataSize != bBox.DataSize {
+		return false, nil
+	}
+
+	const chunkSize = 32 * 1024
+	for position := int64(0); position < int64(aBox.DataSize); position += chunkSize {
+		size := int32(min(chunkSize, int64(aBox.DataSize)-position))
+		aBuf, bBuf := &bytes.Buffer{}, &bytes.Buffer{}
+		err := copy(a, aBox.DataPosition+position, size, aBuf)
+		if err != nil {
+			return false, err
+		}
+		err = copy(b, bBox.DataPosition+position, size, bBuf)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(aBuf.Bytes(), bBuf.Bytes()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func verifyItemList(expected, actual ilst.ItemList) error {
+	expectedValues, err := encodedValueMap(&expected)
+	if err != nil {
+		return err
+	}
+	actualValues, err := encodedValueMap(&actual)
+	if err != nil {
+		return err
+	}
+
+	for id := range ilst.Ids() {
+		if !bytes.Equal(expectedValues[id], actualValues[id]) {
+			return fmt.Errorf("%w: item %s does not match", ErrVerificationFailed, id)
+		}
+	}
+	return nil
+}
+
+func encodedValueMap(itemList *ilst.ItemList) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	for value, err := range ilst.EncodedValues(itemList) {
+		if err != nil {
+			return nil, err
+		}
+		values[value.Id] = append(values[value.Id], value.Bytes...)
+	}
+	return values, nil
+}
@@ -21,6 +21,11 @@ type Box struct {
 	DataPosition  int64
 	DataSize      int32
 	IsContainable bool
+	// Position of the data of the parent box (0 for root level boxes)
+	ParentDataPosition int64
+	// Extended type of `uuid` box (zero for other boxes).
+	// Data of `uuid` box starts with the extended type.
+	ExtendedType [16]byte
 }
 
 const (
@@ -35,14 +40,19 @@ func Walk(rs io.ReadSeeker, size int64) iter.Seq2[Box, error] {
 		acturlYield := func(t Box) (_continue bool) {
 			return yield(t, nil)
 		}
-		err := walkBoxes(rs, size, 0, ROOT_LEVEL /* start from */, ROOT_PATH /* start from */, acturlYield)
+		fileType, err := readFileType(rs, size)
+		if err != nil {
+			yield(Box{}, err)
+			return
+		}
+		err = walkBoxes(rs, size, 0, 0, ROOT_LEVEL /* start from */, ROOT_PATH /* start from */, fileType.QuickTime(), acturlYield)
 		if err != nil && !errors.Is(err, ErrBreakWalk) {
 			yield(Box{}, err)
 		}
 	}
 }
 
-func walkBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, path string, yield func(Box) (_continue bool)) (err error) {
+func walkBoxes(rs io.ReadSeeker, parentEndsAt, parentDataPosition, offset int64, level int8, path string, quickTime bool, yield func(Box) (_continue bool)) (err error) {
 	startPosition, err := rs.Seek(offset, io.SeekStart)
 	if err != nil {
 		return err
@@ -55,7 +65,14 @@ func walkBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, path st
 	if err != nil {
 		return err
 	}
+	if boxSize < 8 /* size, name */ {
+		return fmt.Errorf("invalid box size (%s: %d)", path+"."+boxName, boxSize)
+	}
 	endPosition := startPosition + int64(boxSize)
+	extendedType, err := readExtendedType(rs, boxSize, boxName)
+	if err != nil {
+		return err
+	}
 
 	_continue := yield(Box{
 		Name:          boxName,
@@ -64,17 +81,24 @@ func walkBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, path st
 		DataPosition:  startPosition + 8, /* add size (bytes) of fixed fields (size, name)) */
 		DataSize:      boxSize - 8,       /* add size (bytes) of fixed fields (size, name)) */
 		IsContainable: false,
+
+		ParentDataPosition: parentDataPosition,
+		ExtendedType:       extendedType,
 	})
 	if !_continue {
 		return ErrBreakWalk
 	}
 
-	if containableBox(boxName) {
+	if containableBox(path, boxName) {
 		childOffset := startPosition + 8 /* add size (bytes) of fixed fields (size, name)) */
 		if boxName == "meta" {
-			childOffset += 4 /* bytes */
+			headerSize, err := metaHeaderSize(rs, childOffset, boxSize-8, quickTime)
+			if err != nil {
+				return err
+			}
+			childOffset += headerSize
 		}
-		err = walkBoxes(rs, endPosition, childOffset, level+1, path+"."+boxName, yield)
+		err = walkBoxes(rs, endPosition, childOffset, childOffset, level+1, path+"."+boxName, quickTime, yield)
 		if err != nil {
 			return err
 		}
@@ -85,6 +109,9 @@ func walkBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, path st
 			DataPosition:  startPosition + 8, /* add size (bytes) of fixed fields (size, name)) */
 			DataSize:      boxSize - 8,       /* add size (bytes) of fixed fields (size, name)) */
 			IsContainable: true,
+
+			ParentDataPosition: parentDataPosition,
+			ExtendedType:       extendedType,
 		})
 		if !_continue {
 			return ErrBreakWalk
@@ -95,18 +122,24 @@ func walkBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, path st
 	if err != nil {
 		return err
 	}
-	return walkBoxes(rs, parentEndsAt, nextStartPosition, level, path, yield)
+	return walkBoxes(rs, parentEndsAt, parentDataPosition, nextStartPosition, level, path, quickTime, yield)
 }
 
-func containableBox(boxName string) bool {
-	for id := range ilst.Ids() {
-		if id == boxName {
-			return true
+func containableBox(parentPath, boxName string) bool {
+	if /* item of `mdta` metadata (named by key index) */ parentPath == ".moov.meta.ilst" {
+		return true
+	}
+	if /* item of ItemList */ parentPath == ".moov.udta.meta.ilst" {
+		for id := range ilst.Ids() {
+			if id == boxName {
+				return true
+			}
 		}
 	}
 	return slices.Contains([]string{"moov",
-		"udta", "meta", "ilst",
+		"udta", "meta", "ilst", "----",
 		"trak", "mdia", "minf", "stbl",
+		"moof", "traf", "mfra",
 	}, boxName)
 }
 
@@ -121,14 +154,19 @@ func WritableWalk(rs io.ReadSeeker, size int64, dest io.Writer) iter.Seq2[Writab
 		acturlYield := func(t WritableBox) (_continue bool) {
 			return yield(t, nil)
 		}
-		err := walkCopyBoxes(rs, size, 0, ROOT_LEVEL /* start from */, ROOT_PATH /* start from */, dest, acturlYield)
+		fileType, err := readFileType(rs, size)
+		if err != nil {
+			yield(WritableBox{}, err)
+			return
+		}
+		err = walkCopyBoxes(rs, size, 0, 0, ROOT_LEVEL /* start from */, ROOT_PATH /* start from */, fileType.QuickTime(), dest, acturlYield)
 		if err != nil && !errors.Is(err, ErrBreakWalk) {
 			yield(WritableBox{}, err)
 		}
 	}
 }
 
-func walkCopyBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, basePath string, dest io.Writer, yield func(box WritableBox) (_continue bool)) (err error) {
+func walkCopyBoxes(rs io.ReadSeeker, parentEndsAt, parentDataPosition, offset int64, level int8, basePath string, quickTime bool, dest io.Writer, yield func(box WritableBox) (_continue bool)) (err error) {
 	startPosition, err := rs.Seek(offset, io.SeekStart)
 	if err != nil {
 		return err
@@ -141,7 +179,14 @@ func walkCopyBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, bas
 	if err != nil {
 		return err
 	}
+	if boxSize < 8 /* size, name */ {
+		return fmt.Errorf("invalid box size (%s: %d)", basePath+"."+boxName, boxSize)
+	}
 	endPosition := startPosition + int64(boxSize)
+	extendedType, err := readExtendedType(rs, boxSize, boxName)
+	if err != nil {
+		return err
+	}
 
 	box := Box{
 		Name:          boxName,
@@ -149,18 +194,28 @@ func walkCopyBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, bas
 		Path:          basePath + "." + boxName,
 		DataPosition:  startPosition + 8, /* add size (bytes) of fixed fields (size, name)) */
 		DataSize:      boxSize - 8,       /* add size (bytes) of fixed fields (size, name)) */
-		IsContainable: containableBox(boxName),
+		IsContainable: containableBox(basePath, boxName),
+
+		ParentDataPosition: parentDataPosition,
+		ExtendedType:       extendedType,
 	}
 
 	if box.IsContainable {
 		childOffset := box.DataPosition
 		childBuf := &bytes.Buffer{}
 		if box.Name == "meta" {
-			childBuf.Write(bytes.Repeat([]byte{0x0}, 4))
-			childOffset += 4 /* bytes */
+			headerSize, err := metaHeaderSize(rs, childOffset, box.DataSize, quickTime)
+			if err != nil {
+				return err
+			}
+			err = copy(rs, childOffset, int32(headerSize), childBuf)
+			if err != nil {
+				return err
+			}
+			childOffset += headerSize
 		}
 		slog.Debug(fmt.Sprintf("%-36s    ->", box.Path))
-		err = walkCopyBoxes(rs, endPosition, childOffset, level+1, box.Path, childBuf, yield)
+		err = walkCopyBoxes(rs, endPosition, childOffset, childOffset, level+1, box.Path, quickTime, childBuf, yield)
 		if err != nil {
 			return err
 		}
@@ -168,30 +223,60 @@ func walkCopyBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, bas
 			box.Path, box.DataSize, childBuf.Len(), int32(childBuf.Len())-box.DataSize,
 		))
 
-		var insertBoxes map[string][]byte = map[string][]byte{}
+		var (
+			replaced      bool          = false
+			replacedChild *bytes.Buffer = &bytes.Buffer{}
+		)
+		writer := func(data []byte) (size int32, err error) {
+			if replaced {
+				return 0, fmt.Errorf("`%s` already written", box.Path)
+			}
+			replaced = true
+			_, err = replacedChild.Write(data)
+			if err != nil {
+				return 0, err
+			}
+			return int32(replacedChild.Len()), nil
+		}
+		var insertBoxes []struct {
+			name string
+			data []byte
+		}
 		nextBoxWriter := func(name string, data []byte) (size int32, err error) {
-			insertBoxes[name] = data
+			insertBoxes = append(insertBoxes, struct {
+				name string
+				data []byte
+			}{name, data})
 			boxLengthWillWrite := int32(len(data) + 4 + 4)
 			return boxLengthWillWrite, nil
 		}
 		_continue := yield(WritableBox{box,
-			nil, // containable box does not support modify content
+			writer, // replaces the walked children
 			nextBoxWriter,
 		})
 		if !_continue {
 			return ErrBreakWalk
 		}
-		if childBuf.Len() != 0 {
+		if replaced {
+			slog.Debug(fmt.Sprintf("%-36s    *  %8d -> %8d (%+d)\n",
+				box.Path, childBuf.Len(), replacedChild.Len(), replacedChild.Len()-childBuf.Len(),
+			))
+			childBuf = replacedChild
+		}
+		if /* freeform item without `data` box */ box.Name == "----" && !containsBox(childBuf.Bytes(), "data") {
+			childBuf.Reset()
+		}
+		if childBuf.Len() != 0 || /* keep to append items */ box.Path == ".moov.udta.meta.ilst" {
 			err = writeBox(dest, box.Name, childBuf.Bytes())
 			if err != nil {
 				return fmt.Errorf("failed to write box: %w", err)
 			}
 		}
-		for name, data := range insertBoxes {
-			insertBoxPath := basePath + "." + name
-			insertBoxLength := len(data) + 8
+		for _, insertBox := range insertBoxes {
+			insertBoxPath := basePath + "." + insertBox.name
+			insertBoxLength := len(insertBox.data) + 8
 			slog.Debug(fmt.Sprintf("%-36s    +  %8d -> %8d (%+d)\n", insertBoxPath, 0, insertBoxLength, insertBoxLength))
-			err = writeBox(dest, name, data)
+			err = writeBox(dest, insertBox.name, insertBox.data)
 			if err != nil {
 				return fmt.Errorf("failed to write box: %w (%s)", err, insertBoxPath)
 			}
@@ -240,7 +325,55 @@ func walkCopyBoxes(rs io.ReadSeeker, parentEndsAt, offset int64, level int8, bas
 	if err != nil {
 		return err
 	}
-	return walkCopyBoxes(rs, parentEndsAt, nextStartPosition, level, basePath, dest, yield)
+	return walkCopyBoxes(rs, parentEndsAt, parentDataPosition, nextStartPosition, level, basePath, quickTime, dest, yield)
+}
+
+// metaHeaderSize returns the size of the version and flags fields of `meta` box.
+// `meta` is a full box in ISO base media files and a plain box in QuickTime movie files,
+// but both are found in either file (e.g. `.moov.udta.meta` of `.mov` written as a full box),
+// so the file type decides only if the content does not tell which.
+func metaHeaderSize(rs io.ReadSeeker, dataPosition int64, dataSize int32, quickTime bool) (int64, error) {
+	const fullBoxHeaderSize = 4 /* version, flags */
+	defaultSize := int64(fullBoxHeaderSize)
+	if quickTime {
+		defaultSize = 0
+	}
+	if dataSize < fullBoxHeaderSize+8 {
+		return min(defaultSize, int64(dataSize)), nil
+	}
+
+	_, err := rs.Seek(dataPosition, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	buf, err := binary.Read(rs, fullBoxHeaderSize+8)
+	if err != nil {
+		return 0, err
+	}
+	plain := boxHeaderLike(buf[:8], dataSize)
+	full := boxHeaderLike(buf[fullBoxHeaderSize:], dataSize-fullBoxHeaderSize)
+	switch {
+	case plain && !full:
+		return 0, nil
+	case full && !plain:
+		return fullBoxHeaderSize, nil
+	default:
+		return defaultSize, nil
+	}
+}
+
+// boxHeaderLike reports whether the bytes look like the header of a box (size and name) that fits in the parent.
+func boxHeaderLike(buf []byte, parentDataSize int32) bool {
+	size, err := binary.BigEdian.ReadI32(bytes.NewReader(buf[:4]))
+	if err != nil || size < 8 || size > parentDataSize {
+		return false
+	}
+	for _, c := range buf[4:8] {
+		if (c < 0x20 || 0x7E < c) && c != 0xA9 /* © */ {
+			return false
+		}
+	}
+	return true
 }
 
 func readBoxHeader(rs io.ReadSeeker) (size int32, name string, err error) {
@@ -261,6 +394,19 @@ func readBoxHeader(rs io.ReadSeeker) (size int32, name string, err error) {
 	return size, name, nil
 }
 
+// readExtendedType reads the extended type of `uuid` box after the box header.
+// Zero is returned if the box is too small to have the extended type.
+func readExtendedType(rs io.ReadSeeker, boxSize int32, boxName string) (extendedType [16]byte, err error) {
+	if boxName != "uuid" || boxSize < 8+16 /* size, name, extended type */ {
+		return extendedType, nil
+	}
+	buf, err := binary.Read(rs, 16)
+	if err != nil {
+		return extendedType, err
+	}
+	return [16]byte(buf), nil
+}
+
 func writeBox(dest io.Writer, name string, data []byte) error {
 	_, err := dest.Write(binary.BigEdian.BytesI32(int32(len(data) + 8)))
 	if err != nil {
@@ -283,6 +429,24 @@ func writeBox(dest io.Writer, name string, data []byte) error {
 	return nil
 }
 
+func containsBox(buf []byte, name string) bool {
+	r := bytes.NewReader(buf)
+	for r.Len() != 0 {
+		size, boxName, err := readBoxHeader(r)
+		if err != nil || size < 8 {
+			return false
+		}
+		if boxName == name {
+			return true
+		}
+		_, err = r.Seek(int64(size-8), io.SeekCurrent)
+		if err != nil {
+			return false
+		}
+	}
+	return false
+}
+
 func copy(rs io.ReadSeeker, position int64, size int32, w io.Writer) error {
 	_, err := rs.Seek(position, io.SeekStart)
 	if err != nil {
@@ -11,12 +11,25 @@ import (
 	"os"
 
 	"github.com/tingtt/iterutil"
+	"github.com/tingtt/qtffilst/chapter"
+	"github.com/tingtt/qtffilst/id3"
 	"github.com/tingtt/qtffilst/ilst"
-	"github.com/tingtt/qtffilst/internal/binary"
+	"github.com/tingtt/qtffilst/mdta"
+	"github.com/tingtt/qtffilst/udta"
 )
 
 type Writer interface {
 	Write(dest, tmpDest, tmpDest2 *os.File, tags ilst.ItemList, deleteIds []string) error
+	WriteRawItems(dest, tmpDest *os.File, items []RawItem, deleteIds []string) error
+	Plan(tags ilst.ItemList, deleteIds []string) (Plan, error)
+	Verify(dest *os.File, tags ilst.ItemList, deleteIds []string) error
+	WriteMetadata(dest, tmpDest *os.File, metadata mdta.Metadata, deleteKeys []string) error
+	WriteUserData(dest, tmpDest *os.File, userData udta.UserData, deleteIds []string) error
+	WriteAssets(dest, tmpDest *os.File, assets udta.Assets, deleteIds []string) error
+	WriteXMP(dest, tmpDest *os.File, packet []byte) error
+	WriteID32(dest, tmpDest *os.File, id32 *id3.ID32) error
+	WriteChapters(dest, tmpDest *os.File, chapters []chapter.Chapter) error
+	WriteFastStart(dest, tmpDest *os.File, padding int32) error
 }
 
 type ReadWriter interface {
@@ -65,6 +78,8 @@ func (r *readWriter) Read() (ilst.ItemList, error) {
 	return r.reader.Read()
 }
 
+// Write writes the items of newItemList to `.moov.udta.meta.ilst`, and removes the items of deleteIds.
+// `.moov.udta.meta.ilst` (and `.moov.udta.meta`, `.moov.udta`) is created if it does not exist.
 func (r *readWriter) Write(dest, tmpDest, tmpDest2 *os.File, newItemList ilst.ItemList, deleteIds []string) error {
 	modifyItemIds := maps.Collect(ilst.Values(&newItemList))
 	for _, deleteId := range deleteIds {
@@ -80,7 +95,6 @@ func (r *readWriter) Write(dest, tmpDest, tmpDest2 *os.File, newItemList ilst.It
 		return err
 	}
 
-	ilstSizeDiff := int32(0)
 	oldItemList := ilst.ItemList{}
 	lastLoadedIlstBoxName := ""
 
@@ -93,31 +107,37 @@ func (r *readWriter) Write(dest, tmpDest, tmpDest2 *os.File, newItemList ilst.It
 			panic(fmt.Sprintf("box writer is nil (path: %s)", box.Path))
 		}
 
+		ilstBoxName, err := ilstItemId(r.f, box.Box)
+		if err != nil {
+			return err
+		}
+		if !supportedIlstItem(ilstBoxName) {
+			continue
+		}
+
 		buf := &bytes.Buffer{}
 		err = copy(r.f, box.DataPosition, box.DataSize, buf)
 		if err != nil {
 			return err
 		}
 
-		ilstBoxName := ilstDataBoxName(box.Path)
-		lastLoadedIlstBoxName = ilstBoxName
-
 		err = oldItemList.SetDecoded(ilstBoxName, buf.Bytes())
 		if err != nil {
 			return err
 		}
 
-		if /* expect remove */ v, exists := modifyItemIds[ilstBoxName]; exists && v == nil {
+		if /* expect remove */ v, exists := modifyItemIds[ilstBoxName]; exists && (v == nil || ilst.MultipleData(ilstBoxName)) {
 			// Remove matched box
+			// (item that can have multiple `data` boxes will be appended as new box)
 			_, err = box.Write(nil)
 			if err != nil {
 				return err
 			}
 			slog.Info("remove", slog.String("id", ilstBoxName), slog.String("diff", fmt.Sprintf("%+d", -box.DataSize)))
-
-			ilstSizeDiff -= box.DataSize /* current box size */ + 16 /* parent box header size */
 			continue
 		}
+		// Removed box cannot be used as the position to append remaining items
+		lastLoadedIlstBoxName = ilstDataBoxName(box.Path)
 
 		// Modify matched box
 		boxNameMatcher := func(v ilst.EncodedValue) bool { return v.Id == ilstBoxName }
@@ -135,12 +155,16 @@ func (r *readWriter) Write(dest, tmpDest, tmpDest2 *os.File, newItemList ilst.It
 				return err
 			}
 			slog.Info("modify", slog.String("id", ilstBoxName), slog.String("diff", fmt.Sprintf("%+d", size-box.DataSize)))
-
-			ilstSizeDiff += size - box.DataSize
 			break
 		}
 	}
 
+	for id, v := range modifyItemIds {
+		if /* removed, or not found to remove */ v == nil {
+			delete(modifyItemIds, id)
+		}
+	}
+
 	stat, err := tmpDest.Stat()
 	if err != nil {
 		return err
@@ -156,11 +180,53 @@ func (r *readWriter) Write(dest, tmpDest, tmpDest2 *os.File, newItemList ilst.It
 			return err
 		}
 	} else {
+		// Group `data` boxes by item
+		appendIds := []string{}
+		appendBoxes := map[string]*bytes.Buffer{}
+		for value, err := range ilst.EncodedValues(&newItemList) {
+			if err != nil {
+				return err
+			}
+
+			if _, exists := modifyItemIds[value.Id]; !exists {
+				continue
+			}
+			buf, exists := appendBoxes[value.Id]
+			if !exists {
+				buf = bytes.NewBuffer(ilst.FreeformItemHeader(value.Id))
+				appendBoxes[value.Id] = buf
+				appendIds = append(appendIds, value.Id)
+			}
+			err = writeBox(buf, "data", value.Bytes)
+			if err != nil {
+				return err
+			}
+		}
+		appendBoxName := func(id string) string {
+			if _, _, freeform := ilst.ParseFreeformId(id); freeform {
+				return ilst.FreeformBoxName
+			}
+			return id
+		}
+
+		layout, err := readIlstLayout(tmpDest, stat.Size())
+		if err != nil {
+			return err
+		}
+
 		// Create remaining items `.moov.udta.meta.ilst`
-		matchLastItemListBox := func(box WritableBox) bool {
+		// next to the last item, or as the children of ilst if no items are left.
+		// `.moov.udta.meta.ilst` is created with the items if it does not exist.
+		matchAppendPosition := func(box WritableBox) bool {
+			if !layout.ilstExists {
+				return box.Path == ".moov.udta" || box.Path == ".moov.trak"
+			}
+			if lastLoadedIlstBoxName == "" {
+				return box.Path == ".moov.udta.meta.ilst"
+			}
 			return box.Path == fmt.Sprintf(".moov.udta.meta.ilst.%s", lastLoadedIlstBoxName)
 		}
-		for box, err := range iterutil.FilterKeyFunc(WritableWalk(tmpDest, stat.Size(), tmpDest2), matchLastItemListBox) {
+		for box, err := range iterutil.FilterKeyFunc(WritableWalk(tmpDest, stat.Size(), tmpDest2), matchAppendPosition) {
 			if err != nil {
 				return err
 			}
@@ -168,114 +234,168 @@ func (r *readWriter) Write(dest, tmpDest, tmpDest2 *os.File, newItemList ilst.It
 				panic(fmt.Sprintf("box writer is nil (path: %s)", box.Path))
 			}
 
-			for value, err := range ilst.EncodedValues(&newItemList) {
+			if !layout.ilstExists {
+				children := &bytes.Buffer{}
+				for _, id := range appendIds {
+					err = writeBox(children, appendBoxName(id), appendBoxes[id].Bytes())
+					if err != nil {
+						return err
+					}
+				}
+				created, err := layout.createIlst(tmpDest, box, children.Bytes())
 				if err != nil {
 					return err
 				}
-
-				if _, exists := modifyItemIds[value.Id]; !exists {
+				if !created {
 					continue
 				}
-				delete(modifyItemIds, value.Id)
-				buf := &bytes.Buffer{}
-				err = writeBox(buf, "data", value.Bytes)
+				for _, id := range appendIds {
+					delete(modifyItemIds, id)
+					slog.Info("append", slog.String("id", id), slog.String("diff", fmt.Sprintf("%+d", appendBoxes[id].Len()+8)))
+				}
+				layout.ilstExists = true
+				continue
+			}
+
+			if box.Path == ".moov.udta.meta.ilst" {
+				children := &bytes.Buffer{}
+				err = copy(tmpDest, box.DataPosition, box.DataSize, children)
 				if err != nil {
 					return err
 				}
-				size, err := box.InsertNewBox(value.Id, buf.Bytes())
+				for _, id := range appendIds {
+					if _, exists := modifyItemIds[id]; !exists {
+						continue
+					}
+					err = writeBox(children, appendBoxName(id), appendBoxes[id].Bytes())
+					if err != nil {
+						return err
+					}
+					delete(modifyItemIds, id)
+					slog.Info("append", slog.String("id", id), slog.String("diff", fmt.Sprintf("%+d", appendBoxes[id].Len()+8)))
+				}
+				_, err = box.Write(children.Bytes())
 				if err != nil {
 					return err
 				}
-				slog.Info("append", slog.String("id", value.Id), slog.String("diff", fmt.Sprintf("%+d", size)))
+				continue
+			}
 
-				ilstSizeDiff += size
+			for _, id := range appendIds {
+				if _, exists := modifyItemIds[id]; !exists {
+					continue
+				}
+				delete(modifyItemIds, id)
+				size, err := box.InsertNewBox(appendBoxName(id), appendBoxes[id].Bytes())
+				if err != nil {
+					return err
+				}
+				slog.Info("append", slog.String("id", id), slog.String("diff", fmt.Sprintf("%+d", size)))
 			}
 		}
+		if len(modifyItemIds) != 0 {
+			return ErrIlstBoxDoesNotExist
+		}
 	}
 
-	stat2, err := tmpDest2.Stat()
-	if err != nil {
-		return err
-	}
+	return r.copyWithOffsetsPatch(tmpDest2, dest)
+}
+
+// ilstLayout is the existence of `.moov.udta.meta.ilst` and the boxes containing it.
+type ilstLayout struct {
+	udtaExists bool
+	metaExists bool
+	ilstExists bool
+	trakCount  int
+	// Count of the tracks walked on createIlst
+	walkedTrakCount int
+}
 
-	if ilstSizeDiff == 0 {
-		slog.Debug("skip modification of chunk offset because .moov.udta.meta.ilst has no size changes", slog.String("diff", fmt.Sprintf("%+d", ilstSizeDiff)))
-		_, err := tmpDest2.Seek(0, io.SeekStart)
+func readIlstLayout(rs io.ReadSeeker, size int64) (*ilstLayout, error) {
+	layout := &ilstLayout{}
+	for box, err := range Walk(rs, size) {
 		if err != nil {
-			return err
+			return nil, err
+		}
+		switch {
+		case box.Path == ".moov.udta":
+			layout.udtaExists = true
+		case box.Path == ".moov.udta.meta":
+			layout.metaExists = true
+		case box.Path == ".moov.udta.meta.ilst":
+			layout.ilstExists = true
+		case box.Path == ".moov.trak" && box.IsContainable:
+			layout.trakCount++
 		}
-		_, err = io.Copy(dest, tmpDest2)
-		return err
 	}
-
-	mdatFoundBeforeIlst, err := mdatBoxIsBeforeIlst(Walk(tmpDest2, stat2.Size()))
-	if err != nil {
-		return err
+	if layout.ilstExists {
+		return layout, nil
 	}
-	if mdatFoundBeforeIlst {
-		slog.Debug("skip modification of chunk offset because .mdat exists before .moov.udta.meta.ilst", slog.String("diff", fmt.Sprintf("%+d", ilstSizeDiff)))
-		tmpDest2.Seek(0, io.SeekStart)
-		_, err := io.Copy(dest, tmpDest2)
-		return err
+	if /* other handler (e.g. ID32) */ layout.metaExists {
+		return nil, ErrIlstBoxDoesNotExist
 	}
-
-	// Modify `.moov.trak.mdia.minf.stbl.stco`
-	slog.Info("modify chunk offsets", slog.String("diff", fmt.Sprintf("%+d", ilstSizeDiff)))
-	matchSampleTableChunkOffsetBox := func(box WritableBox) bool {
-		return box.Path == ".moov.trak.mdia.minf.stbl.stco"
+	if !layout.udtaExists && layout.trakCount == 0 {
+		return nil, ErrTrackDoesNotExist
 	}
-	for box, err := range iterutil.FilterKeyFunc(WritableWalk(tmpDest2, stat2.Size(), dest), matchSampleTableChunkOffsetBox) {
-		if err != nil {
-			return err
-		}
-
-		// Data format
-		// https://developer.apple.com/documentation/quicktime-file-format/chunk_offset_atom
-		buf := &bytes.Buffer{}
-
-		{ // copy fixed fields (version, flags, number of entries)
-			err = copy(tmpDest2, box.DataPosition, 8, buf)
-			if err != nil {
-				return err
-			}
-		}
+	return layout, nil
+}
 
-		var entryCount int32
-		{ // get entry count
-			_, err = tmpDest2.Seek(box.DataPosition+4, io.SeekStart)
-			if err != nil {
-				return err
-			}
-			entryCount, err = binary.BigEdian.ReadI32(tmpDest2)
-			if err != nil {
-				return err
-			}
-		}
-
-		// create new chunk offset table
-		positionOfChunkOffsetTable := box.DataPosition + 8
-		tmpDest2.Seek(positionOfChunkOffsetTable, io.SeekStart) // Seek to chunk offset table
-		for range entryCount {
-			offset, err := binary.BigEdian.ReadI32(tmpDest2)
-			if err != nil {
-				return err
-			}
-			slog.Debug(fmt.Sprintf("offset: %8d -> %8d (%+d)\n", offset, offset+ilstSizeDiff, ilstSizeDiff))
-			newOffset := binary.BigEdian.BytesI32(offset + ilstSizeDiff)
-			_, err = buf.Write(newOffset)
-			if err != nil {
-				return err
-			}
+// createIlst creates `.moov.udta.meta.ilst` with the items (encoded item boxes) on the walk of WritableWalk of rs.
+// `.moov.udta.meta` is appended to `.moov.udta`, or `.moov.udta` is appended after the last track if it does not exist.
+// created is false if the box is not the position to create.
+func (l *ilstLayout) createIlst(rs io.ReadSeeker, box WritableBox, items []byte) (created bool, err error) {
+	switch {
+	case box.Path == ".moov.udta" && box.IsContainable:
+	case box.Path == ".moov.trak" && box.IsContainable && !l.udtaExists:
+		l.walkedTrakCount++
+		if l.walkedTrakCount != l.trakCount {
+			return false, nil
 		}
+	default:
+		return false, nil
+	}
 
-		// write new `.moov.trak.mdia.minf.stbl.stco`
-		_, err = box.Write(buf.Bytes())
+	// `.moov.udta.meta` is a full box in both ISO base media files and QuickTime movie files
+	meta := &bytes.Buffer{}
+	meta.Write(bytes.Repeat([]byte{0x0}, 4) /* version, flags */)
+	err = writeBox(meta, "hdlr", ilstHandlerData())
+	if err != nil {
+		return false, err
+	}
+	err = writeBox(meta, "ilst", items)
+	if err != nil {
+		return false, err
+	}
+	children := &bytes.Buffer{}
+	if box.Path == ".moov.udta" {
+		err = copy(rs, box.DataPosition, box.DataSize, children)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
+	err = writeBox(children, "meta", meta.Bytes())
+	if err != nil {
+		return false, err
+	}
 
-	return nil
+	if box.Path == ".moov.udta" {
+		_, err = box.Write(children.Bytes())
+		return true, err
+	}
+	_, err = box.InsertNewBox("udta", children.Bytes())
+	return true, err
+}
+
+// https://developer.apple.com/documentation/quicktime-file-format/handler_reference_atom
+func ilstHandlerData() []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(bytes.Repeat([]byte{0x0}, 4) /* version, flags */)
+	buf.Write(bytes.Repeat([]byte{0x0}, 4) /* predefined */)
+	buf.Write([]byte(ilst.HandlerType))
+	buf.Write([]byte("appl") /* manufacturer */)
+	buf.Write(bytes.Repeat([]byte{0x0}, 8) /* reserved */)
+	buf.Write([]byte{0x0} /* name */)
+	return buf.Bytes()
 }
 
 func WalkSupportedWritabelBox(rw iter.Seq2[WritableBox, error]) iter.Seq2[WritableBox, error] {
@@ -288,20 +408,3 @@ func WalkSupportedWritabelBox(rw iter.Seq2[WritableBox, error]) iter.Seq2[Writab
 var (
 	ErrIlstBoxDoesNotExist = errors.New(".moov.udta.meta.ilst does not exists")
 )
-
-func mdatBoxIsBeforeIlst(seq iter.Seq2[Box, error]) (bool, error) {
-	mdatFound := false
-	for box, err := range seq {
-		if err != nil {
-			return false, err
-		}
-
-		switch box.Path {
-		case ".mdat":
-			mdatFound = true
-		case ".moov.udta.meta.ilst":
-			return mdatFound, nil
-		}
-	}
-	return false, errors.New(".moov.udta.meta.ilst does not exists")
-}
@@ -0,0 +1,97 @@
+package qtffilst
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tingtt/qtffilst/ilst"
+)
+
+func TestWriteCreatesIlst(t *testing.T) {
+	ftyp := testBox("ftyp", []byte("M4A "), u32(0), []byte("M4A isom"))
+	mdat := testBox("mdat", []byte("chunk1"))
+	trak := func(offset int64) []byte {
+		return testBox("trak", testBox("mdia", testBox("minf", testBox("stbl", testBox("stco", u32(0), u32(1), u32(offset))))))
+	}
+	// moov with the trak, followed by mdat
+	file := func(moovChildren ...[]byte) []byte {
+		size := len(ftyp) + len(testBox("moov", slices.Concat(moovChildren...))) + len(trak(0)) + 8
+		return slices.Concat(ftyp, testBox("moov", slices.Concat(moovChildren...), trak(int64(size))), mdat)
+	}
+
+	tests := []struct {
+		name    string
+		src     []byte
+		wantErr error
+	}{
+		{"without udta", file(), nil},
+		{"udta without meta", file(testBox("udta", testBox("©nam", u32(0)))), nil},
+		{"meta without ilst", file(testBox("udta", testBox("meta", u32(0), testBox("hdlr", make([]byte, 25))))), ErrIlstBoxDoesNotExist},
+		{"without trak", slices.Concat(ftyp, testBox("moov"), mdat), ErrTrackDoesNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "src"), tt.src, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			r, err := open(filepath.Join(dir, "src"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.f.Close()
+			files := []*os.File{}
+			for _, name := range []string{"dest", "tmp", "tmp2"} {
+				f, err := os.Create(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				files = append(files, f)
+			}
+
+			itemList := ilst.ItemList{TitleC: ilst.NewInternationalText("Title")}
+			err = r.Write(files[0], files[1], files[2], itemList, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			written, err := open(filepath.Join(dir, "dest"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer written.f.Close()
+			got, err := written.Read()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got.TitleC == nil || got.TitleC.Text != "Title" {
+				t.Errorf("title = %+v, want \"Title\"", got.TitleC)
+			}
+
+			// chunk offset points to the same data
+			data, err := os.ReadFile(filepath.Join(dir, "dest"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for box, err := range Walk(written.f, written.size) {
+				if err != nil {
+					t.Fatalf("error = %v", err)
+				}
+				if box.Path != ".moov.trak.mdia.minf.stbl.stco" {
+					continue
+				}
+				offset := readOffset(data[box.DataPosition+8 : box.DataPosition+12])
+				if string(data[offset:offset+6]) != "chunk1" {
+					t.Errorf("chunk offset = %d, points to %q", offset, data[offset:offset+6])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package qtffilst
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/tingtt/qtffilst/xmp"
+)
+
+// ReadXMP reads the XMP packet of `uuid` box at the top level or under `.moov`.
+// Returns nil if XMP packet does not exist.
+func (r *reader) ReadXMP() ([]byte, error) {
+	for box, err := range Walk(r.f, r.size) {
+		if err != nil {
+			return nil, err
+		}
+		if !xmpBox(box) {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		err = copy(r.f, box.DataPosition+16 /* extended type */, box.DataSize-16, buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, nil
+}
+
+// WriteXMP replaces the XMP packet of `uuid` box, or removes it if the packet is nil.
+// `uuid` box is appended at the end of the file if it does not exist,
+// or inserted before `mfra` of the fragmented file to keep `mfra` and `mfro` at the end.
+func (r *readWriter) WriteXMP(dest, tmpDest *os.File, packet []byte) error {
+	data := slices.Concat(xmp.UUID[:], packet)
+
+	written := false
+	for box, err := range WritableWalk(r.f, r.size, tmpDest) {
+		if err != nil {
+			return err
+		}
+		if box.Path == ".mfra" && packet != nil && !written {
+			// boxes before `mfra` are already written to tmpDest
+			err = writeBox(tmpDest, "uuid", data)
+			if err != nil {
+				return err
+			}
+			written = true
+			slog.Info("append", slog.String("path", ".uuid"))
+			continue
+		}
+		if !xmpBox(box.Box) {
+			continue
+		}
+
+		if packet == nil || written {
+			_, err = box.Write(nil)
+			if err != nil {
+				return err
+			}
+			slog.Info("remove", slog.String("path", box.Path))
+			continue
+		}
+		_, err = box.Write(data)
+		if err != nil {
+			return err
+		}
+		written = true
+		slog.Info("modify", slog.String("path", box.Path))
+	}
+
+	if packet != nil && !written {
+		_, err := tmpDest.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		err = writeBox(tmpDest, "uuid", data)
+		if err != nil {
+			return err
+		}
+		slog.Info("append", slog.String("path", ".uuid"))
+	}
+
+	return r.copyWithOffsetsPatch(tmpDest, dest)
+}
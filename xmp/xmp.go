@@ -0,0 +1,75 @@
+package xmp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// UUID is the extended type of `uuid` box that has XMP packet.
+// BE7ACFCB-97A9-42E8-9C71-999491E3AFAC
+var UUID = [16]byte{0xBE, 0x7A, 0xCF, 0xCB, 0x97, 0xA9, 0x42, 0xE8, 0x9C, 0x71, 0x99, 0x94, 0x91, 0xE3, 0xAF, 0xAC}
+
+// Namespace of Dublin Core elements.
+const NamespaceDublinCore = "http://purl.org/dc/elements/1.1/"
+
+// DublinCore is the minimal view of Dublin Core elements in XMP packet.
+// Values of `rdf:Alt`, `rdf:Bag` and `rdf:Seq` are listed in the order of the packet.
+type DublinCore struct {
+	Title       []string
+	Creator     []string
+	Description []string
+	Subject     []string
+	Rights      []string
+	Date        []string
+}
+
+// ParseDublinCore parses Dublin Core elements of XMP packet.
+func ParseDublinCore(packet []byte) (DublinCore, error) {
+	dc := DublinCore{}
+	fields := map[string]*[]string{
+		"title": &dc.Title, "creator": &dc.Creator, "description": &dc.Description,
+		"subject": &dc.Subject, "rights": &dc.Rights, "date": &dc.Date,
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(packet))
+	var (
+		current *[]string // field of the Dublin Core element being read
+		depth   int       // depth in the Dublin Core element
+	)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return dc, nil
+		}
+		if err != nil {
+			return DublinCore{}, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if current != nil {
+				depth++
+				continue
+			}
+			if t.Name.Space == NamespaceDublinCore {
+				current, depth = fields[t.Name.Local], 0
+			}
+		case xml.EndElement:
+			if current == nil {
+				continue
+			}
+			if depth == 0 {
+				current = nil
+				continue
+			}
+			depth--
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); current != nil && text != "" {
+				*current = append(*current, text)
+			}
+		}
+	}
+}